@@ -10,11 +10,11 @@ import (
 )
 
 func Parse(v interface{}) error {
-	return parseInternal(v, setField, defaultOptions())
+	return parse(v, defaultOptions())
 }
 
 func ParseWithOptions(v interface{}, opts Options) error {
-	return parseInternal(v, setField, customOptions(opts))
+	return parse(v, customOptions(opts))
 }
 
 func ParseAs[T any]() (T, error) {
@@ -59,6 +59,13 @@ func GetFieldParamsWithOptions(v interface{}, opts Options) ([]FieldParams, erro
 	return result, nil
 }
 
+// parse 和 parseInternal 的区别是会调用结构体上的 Validate 和 EnvParsed
+func parse(v interface{}, opts Options) error {
+	opts.callHooks = true
+	opts.parsing = true
+	return parseInternal(v, setField, opts)
+}
+
 func parseInternal(v interface{}, processField processFieldFn, opts Options) error {
 	ptrRef := reflect.ValueOf(v)
 	if ptrRef.Kind() != reflect.Ptr {
@@ -68,9 +75,39 @@ func parseInternal(v interface{}, processField processFieldFn, opts Options) err
 	if ref.Kind() != reflect.Struct {
 		return newAggregateError(NotStructPtrError{})
 	}
+	if err := opts.loadSources(); err != nil {
+		return err
+	}
+	if opts.callHooks {
+		prepareParse(ref, &opts)
+	}
 	return doParse(ref, processField, opts)
 }
 
+// prepareParse 在真正解析前遍历一次整个结构体
+// 记录每个字段展开前的值，这样 envDefault 可以引用后面声明的字段，或者其他前缀下的字段
+// 同时收集环境变量中没有值、需要从 SecretProvider 读取的 ref，一次批量读取
+func prepareParse(ref reflect.Value, opts *Options) {
+	var refs []string
+	seen := make(map[string]bool)
+	walkOpts := *opts
+	walkOpts.callHooks = false
+	// 这里的错误在真正解析时会再次出现，所以忽略
+	_ = doParse(ref, func(_ reflect.Value, _ reflect.StructField, o Options, fieldParams FieldParams) error {
+		if needsSecret(fieldParams, o) && !seen[fieldParams.SecretRef] {
+			seen[fieldParams.SecretRef] = true
+			refs = append(refs, fieldParams.SecretRef)
+		}
+		if fieldParams.OwnKey != "" {
+			key := lookupKey(fieldParams, o.Environment)
+			val, exists, isDefault := getOr(key, fieldParams.DefaultValue, fieldParams.HasDefaultValue, o.Environment)
+			o.rawEnvVars[fieldParams.Key] = rawEnvVar{val, exists, isDefault}
+		}
+		return nil
+	}, walkOpts)
+	opts.secrets = fetchSecrets(refs, *opts)
+}
+
 func doParse(ref reflect.Value, processField processFieldFn, opts Options) error {
 	refType := ref.Type()
 	var agrErr AggregateError
@@ -86,6 +123,12 @@ func doParse(ref reflect.Value, processField processFieldFn, opts Options) error
 			}
 		}
 	}
+	if len(agrErr.Errors) == 0 && opts.callHooks {
+		if err := checkConditions(ref, opts); err != nil {
+			return err
+		}
+		return runHooks(ref, opts)
+	}
 	if len(agrErr.Errors) == 0 {
 		return nil
 	}
@@ -98,6 +141,8 @@ func doParseField(refField reflect.Value, refTypeField reflect.StructField, proc
 		return nil
 	}
 
+	opts.fieldPath = joinFieldPath(opts.fieldPath, refTypeField.Name)
+
 	params, err := parseFieldParams(refTypeField, opts)
 	if err != nil {
 		return err
@@ -117,6 +162,14 @@ func doParseField(refField reflect.Value, refTypeField reflect.StructField, proc
 		refField = refField.Elem()
 	}
 
+	if _, ok := refTypeField.Tag.Lookup(opts.PrefixTagName); ok && isStructSlice(refField.Type()) {
+		return doParseSlice(refField, refTypeField, processField, opts)
+	}
+
+	if _, ok := refTypeField.Tag.Lookup(opts.PrefixTagName); ok && isStructMap(refField.Type()) {
+		return doParseStructMap(refField, refTypeField, processField, opts)
+	}
+
 	if refField.Kind() == reflect.Ptr && refField.Elem().Kind() == reflect.Struct {
 		return doParse(refField.Elem(), processField, optionsWithEnvPrefix(refTypeField, opts))
 	}
@@ -130,6 +183,7 @@ func doParseField(refField reflect.Value, refTypeField reflect.StructField, proc
 
 func parseFieldParams(field reflect.StructField, opts Options) (FieldParams, error) {
 	ownKey, tags := parseKeyForOption(field.Tag.Get(opts.TagName))
+	ownKey, aliases := parseKeyAliases(ownKey)
 
 	if ownKey == "" && opts.UseFieldNameByDefault {
 		ownKey = toEnvName(field.Name)
@@ -142,6 +196,29 @@ func parseFieldParams(field reflect.StructField, opts Options) (FieldParams, err
 		DefaultValue:    defaultValue,
 		Required:        opts.RequiredIfNoDef,
 		HasDefaultValue: hasDefaultValue,
+		Description:     field.Tag.Get(opts.DescriptionTagName),
+		Validate:        field.Tag.Get(opts.ValidateTagName),
+		Secret:          hasSecretType(field.Type),
+		SecretRef:       field.Tag.Get(opts.SecretTagName),
+	}
+	if result.SecretRef != "" {
+		result.Secret = true
+	}
+	for _, alias := range aliases {
+		result.Aliases = append(result.Aliases, opts.Prefix+alias)
+	}
+
+	if _, err := parseValidationRules(result.Validate); err != nil {
+		return FieldParams{}, err
+	}
+
+	if pipeline := field.Tag.Get("envFilePipeline"); pipeline != "" {
+		for _, stage := range strings.Split(pipeline, ",") {
+			if _, ok := fileStages[stage]; !ok {
+				return FieldParams{}, newNoSupportedTagOptionError(stage)
+			}
+			result.FilePipeline = append(result.FilePipeline, stage)
+		}
 	}
 
 	for _, tag := range tags {
@@ -160,35 +237,141 @@ func parseFieldParams(field reflect.StructField, opts Options) (FieldParams, err
 			result.Unset = true
 		case "file":
 			result.LoadFile = true
+		case "scan":
+			result.Scan = true
+		case "secret":
+			result.Secret = true
+		case "deprecated":
+			result.Deprecated = true
+		case "resolve":
+			result.Resolve = true
 		default:
-			return FieldParams{}, newNoSupportedTagOptionError(tag)
+			if !parseConditionOption(&result, tag) {
+				return FieldParams{}, newNoSupportedTagOptionError(tag)
+			}
 		}
 	}
+
+	// envFilePipeline 只处理文件的内容，没有 file 选项也没有设置 FileSuffix 时不会生效
+	if len(result.FilePipeline) > 0 && !result.LoadFile && opts.FileSuffix == "" {
+		return FieldParams{}, newNoSupportedTagOptionError("envFilePipeline:" + field.Tag.Get("envFilePipeline"))
+	}
 	return result, nil
 }
 
 func setField(refField reflect.Value, refTypeField reflect.StructField, opts Options, fieldParams FieldParams) error {
-	value, err := get(fieldParams, opts)
+	if fieldParams.Scan {
+		return opts.withFieldPath(setScannedMap(refField, refTypeField, fieldParams, opts))
+	}
+
+	// 解密后的值和 secret 字段一样，不能出现在错误信息和 OnSet 中
+	if isEncrypted(opts.Environment[lookupKey(fieldParams, opts.Environment)]) || isEncrypted(fieldParams.DefaultValue) {
+		fieldParams.Secret = true
+	}
+
+	value, err := get(&fieldParams, opts)
 	if err != nil {
 		return err
 	}
-	if value != "" {
-		return set(refField, refTypeField, value, opts.FuncMap)
+	if ov := asOptionalValue(refField); ov != nil {
+		ov.setPresence(presenceOf(fieldParams, opts))
 	}
-	return nil
+	if value == "" {
+		return nil
+	}
+	err = set(refField, refTypeField, value, opts.FuncMap)
+	if err == nil {
+		err = validate(refField, refTypeField, fieldParams)
+	}
+	err = opts.withFieldPath(redactError(err, fieldParams, secretParts(refTypeField, value)...))
+	opts.reportError(opts.fieldPath, err)
+	return err
 }
 
-func get(fieldParams FieldParams, opts Options) (val string, err error) {
-	val, exists, isDefault := getOr(fieldParams.Key, fieldParams.DefaultValue, fieldParams.HasDefaultValue, opts.Environment)
+// get 读取字段的值，值引用了加密的变量时会把 fieldParams.Secret 设为 true
+func get(fieldParams *FieldParams, opts Options) (val string, err error) {
+	// key 是按顺序找到的第一个有值的 key，可能是别名
+	// 设置了 FileSuffix 时，KEY 没有值就读取 KEY_FILE 指向的文件，别名也一样
+	key, fileKey, err := lookupFileKey(*fieldParams, opts)
+	if err != nil {
+		return "", err
+	}
+	val, exists, isDefault := getOr(key, fieldParams.DefaultValue, fieldParams.HasDefaultValue, opts.Environment)
 
-	if fieldParams.Expand {
-		val = os.Expand(val, opts.getRawEnv)
+	// sourceKey 是实际读取的环境变量
+	sourceKey := key
+	loadFile := fieldParams.LoadFile
+	if fileKey != "" {
+		val, exists, isDefault = opts.Environment[fileKey], true, false
+		sourceKey = fileKey
+		loadFile = true
+	}
+
+	if fieldParams.OwnKey != "" {
+		warnDeprecated(key, *fieldParams, opts)
+	}
+
+	// 环境变量没有值时从 SecretProvider 读取，优先于默认值
+	source := opts.sourceOf(sourceKey, isDefault)
+	if needsSecret(*fieldParams, opts) {
+		if val, err = opts.getSecret(*fieldParams); err != nil {
+			return "", err
+		}
+		exists, isDefault = true, false
+		source = SecretSourcePrefix + fieldParams.SecretRef
+	}
+
+	if opts.resolution != nil && exists && fieldParams.OwnKey != "" {
+		opts.resolution[fieldParams.Key] = source
+	}
+
+	var field *FieldReport
+	if opts.report != nil && fieldParams.OwnKey != "" {
+		_, found := opts.Environment[key]
+		field = &FieldReport{
+			Path:      opts.fieldPath,
+			Key:       fieldParams.Key,
+			Value:     val,
+			Exists:    found,
+			IsDefault: isDefault,
+			Expanded:  fieldParams.Expand,
+			Secret:    fieldParams.Secret,
+		}
+		if fieldParams.Secret && val != "" {
+			field.Value = Redacted
+		}
+		if exists {
+			field.Source = source
+		}
+		defer func() {
+			field.Err = err
+			opts.report.Fields = append(opts.report.Fields, *field)
+		}()
+	}
+
+	// 先解密，解密后的值和其他值一样展开、读取文件和解析
+	if isEncrypted(val) {
+		if val, err = opts.decrypt(val); err != nil {
+			return "", newDecryptError(fieldParams.Key, err)
+		}
 	}
 
-	opts.rawEnvVars[fieldParams.OwnKey] = val
+	if fieldParams.Expand {
+		var secret bool
+		if val, secret, err = opts.expand(fieldParams.Key, val, isDefault); err != nil {
+			return "", err
+		}
+		if secret {
+			fieldParams.Secret = true
+			if field != nil {
+				field.Secret = true
+				field.Value = Redacted
+			}
+		}
+	}
 
 	if fieldParams.Unset {
-		defer os.Unsetenv(fieldParams.Key)
+		defer os.Unsetenv(key)
 	}
 
 	if fieldParams.Required && !exists && fieldParams.OwnKey != "" {
@@ -198,17 +381,42 @@ func get(fieldParams FieldParams, opts Options) (val string, err error) {
 		return "", newEmptyVarError(fieldParams.Key)
 	}
 
-	if fieldParams.LoadFile && val != "" {
-		filename := val
-		val, err = getFromFile(filename)
+	if (fieldParams.Resolve || opts.ResolveAll) && val != "" {
+		raw := val
+		var scheme string
+		if val, scheme, err = opts.resolve(fieldParams.Key, val); err != nil {
+			return "", err
+		}
+		// 和 file 选项一样，记录读取的文件，Holder.WatchFiles 需要用到
+		if scheme == "file" && field != nil && opts.Resolvers["file"] == nil {
+			field.File = opts.filePath(filePathOfURI(strings.TrimPrefix(raw, "file:")))
+		}
+	}
+
+	if loadFile && val != "" {
+		filename := opts.filePath(val)
+		if field != nil {
+			field.File = filename
+		}
+		val, err = getFromFile(opts.FS, filename)
 		if err != nil {
-			return "", newLoadFileContentError(filename, fieldParams.Key, err)
+			return "", newLoadFileContentError(filename, sourceKey, err)
+		}
+		for _, stage := range fieldParams.FilePipeline {
+			if val, err = fileStages[stage](val); err != nil {
+				return "", newDecodeFileContentError(filename, sourceKey, stage, err)
+			}
 		}
 	}
 
+	// 值来自 KEY_FILE 时，OnSet 收到的是 KEY_FILE
 	if opts.OnSet != nil {
 		if fieldParams.OwnKey != "" {
-			opts.OnSet(fieldParams.Key, val, isDefault)
+			if fieldParams.Secret && val != "" {
+				opts.OnSet(sourceKey, Redacted, isDefault)
+			} else {
+				opts.OnSet(sourceKey, val, isDefault)
+			}
 		}
 	}
 
@@ -229,6 +437,10 @@ func getOr(key, defaultValue string, defExists bool, env map[string]string) (val
 }
 
 func set(field reflect.Value, sf reflect.StructField, value string, funcMap map[reflect.Type]ParserFunc) error {
+	if iv := asInnerValue(field); iv != nil {
+		return iv.setInner(value, sf, funcMap)
+	}
+
 	if tm := asTextUnmarshaler(field); tm != nil {
 		if err := tm.UnmarshalText([]byte(value)); err != nil {
 			return newParseError(sf, err)
@@ -302,20 +514,6 @@ func handleSlice(field reflect.Value, value string, sf reflect.StructField, func
 }
 
 func handleMap(field reflect.Value, value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error {
-	// 获取 key 的解析函数
-	keyType := sf.Type.Key()
-	keyParserFunc, ok := getParserFunc(funcMap, keyType)
-	if !ok {
-		return newNoParserError(sf)
-	}
-
-	// 获取 value 的解析函数
-	elemType := sf.Type.Elem()
-	elemParserFunc, ok := getParserFunc(funcMap, elemType)
-	if !ok {
-		return newNoParserError(sf)
-	}
-
 	separator := sf.Tag.Get("envSeparator")
 	if separator == "" {
 		separator = ","
@@ -326,24 +524,47 @@ func handleMap(field reflect.Value, value string, sf reflect.StructField, funcMa
 		keyValSeparator = ":"
 	}
 
-	// 初始化 reflect.map
-	result := reflect.MakeMap(sf.Type)
 	//"k1:v1,k2:v2" => ["k1:v1", "k2,v2"]
+	var entries [][2]string
 	for _, part := range strings.Split(value, separator) {
 		//"k1:v1" => ["k1", "v1"]
 		pairs := strings.Split(part, keyValSeparator)
 		if len(pairs) != 2 {
 			return newParseError(sf, fmt.Errorf(`%q should be in "key%svalue" format`, part, keyValSeparator))
 		}
+		entries = append(entries, [2]string{pairs[0], pairs[1]})
+	}
+
+	return setMap(field, sf, entries, funcMap)
+}
+
+// setMap 把 [key, value] 转换后设置到 map 中
+func setMap(field reflect.Value, sf reflect.StructField, entries [][2]string, funcMap map[reflect.Type]ParserFunc) error {
+	// 获取 key 的解析函数
+	keyType := sf.Type.Key()
+	keyParserFunc, ok := getParserFunc(funcMap, keyType)
+	if !ok {
+		return newNoParserError(sf)
+	}
 
+	// 获取 value 的解析函数
+	elemType := sf.Type.Elem()
+	elemParserFunc, ok := getParserFunc(funcMap, elemType)
+	if !ok {
+		return newNoParserError(sf)
+	}
+
+	// 初始化 reflect.map
+	result := reflect.MakeMap(sf.Type)
+	for _, entry := range entries {
 		// 对 key 做转换
-		key, err := keyParserFunc(pairs[0])
+		key, err := keyParserFunc(entry[0])
 		if err != nil {
 			return newParseError(sf, err)
 		}
 
 		// 对 value 做转换
-		elem, err := elemParserFunc(pairs[1])
+		elem, err := elemParserFunc(entry[1])
 		if err != nil {
 			return newParseError(sf, err)
 		}
@@ -0,0 +1,75 @@
+package env
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// OnDeprecatedFn 在使用了已经废弃的 key 时被调用，oldKey 是实际读取的 key，newKey 是应该使用的 key
+type OnDeprecatedFn func(oldKey, newKey string)
+
+// parseKeyAliases 解析 env tag 中用 "|" 分隔的多个 key
+// "DB_URL|DATABASE_URL" => DB_URL, [DATABASE_URL]
+func parseKeyAliases(key string) (string, []string) {
+	keys := strings.Split(key, "|")
+	if len(keys) == 1 {
+		return key, nil
+	}
+	return keys[0], keys[1:]
+}
+
+// Keys 返回所有候选的 key，第一个是 Key，后面是按顺序查找的别名
+func (p FieldParams) Keys() []string {
+	return append([]string{p.Key}, p.Aliases...)
+}
+
+// lookupKey 按顺序查找第一个有值的 key，都没有值时返回 Key
+// 这样 Key 不存在或者为空时，默认值、required 等规则和没有别名时一样
+func lookupKey(fieldParams FieldParams, env map[string]string) string {
+	if env[fieldParams.Key] != "" {
+		return fieldParams.Key
+	}
+	for _, alias := range fieldParams.Aliases {
+		if env[alias] != "" {
+			return alias
+		}
+	}
+	return fieldParams.Key
+}
+
+// warnDeprecated 在读取了别名，并且字段带有 deprecated 选项时发出警告
+// 没有设置 OnDeprecated 时使用 slog 记录
+func warnDeprecated(key string, fieldParams FieldParams, opts Options) {
+	if key == fieldParams.Key || !fieldParams.Deprecated {
+		return
+	}
+	if opts.OnDeprecated != nil {
+		opts.OnDeprecated(key, fieldParams.Key)
+		return
+	}
+	slog.Warn("env: deprecated environment variable, use the new key instead", "old", key, "new", fieldParams.Key)
+}
+
+// lookupFileKey 在设置了 FileSuffix 时按顺序检查每个候选 key 和对应的 KEY_FILE
+// 第一个有值的 key，或者第一个有值的 KEY_FILE 提供字段的值，fileKey 为空表示值来自 key 本身
+// 和 lookupKey 一样，空的 KEY_FILE 当作没有设置，这样 envDefault 和 required 仍然生效
+// 同一个候选 key 和它的 KEY_FILE 都有值时返回 FileSuffixConflictError
+func lookupFileKey(fieldParams FieldParams, opts Options) (key, fileKey string, err error) {
+	key = lookupKey(fieldParams, opts.Environment)
+	if opts.FileSuffix == "" || fieldParams.OwnKey == "" || fieldParams.LoadFile {
+		return key, "", nil
+	}
+	for _, candidate := range fieldParams.Keys() {
+		hasFile := opts.Environment[candidate+opts.FileSuffix] != ""
+		if opts.Environment[candidate] != "" {
+			if hasFile {
+				return "", "", newFileSuffixConflictError(candidate, candidate+opts.FileSuffix)
+			}
+			return candidate, "", nil
+		}
+		if hasFile {
+			return candidate, candidate + opts.FileSuffix, nil
+		}
+	}
+	return key, "", nil
+}
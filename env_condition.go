@@ -0,0 +1,102 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+)
+
+// parseConditionOption 解析依赖同级字段的 tag 选项
+// required_if=Mode:tls  当 Mode 的值是 tls 时，当前字段不能为空
+// required_with=Cert    当 Cert 不为空时，当前字段不能为空
+// excluded_with=Insecure 当 Insecure 不为空时，当前字段必须为空
+func parseConditionOption(result *FieldParams, tag string) bool {
+	name, arg, ok := strings.Cut(tag, "=")
+	if !ok || arg == "" {
+		return false
+	}
+	switch name {
+	case "required_if":
+		if !strings.Contains(arg, ":") {
+			return false
+		}
+		result.RequiredIf = arg
+	case "required_with":
+		result.RequiredWith = arg
+	case "excluded_with":
+		result.ExcludedWith = arg
+	default:
+		return false
+	}
+	return true
+}
+
+// checkConditions 在同一层结构体的字段都解析完之后再检查，这样不用关心字段的声明顺序
+func checkConditions(ref reflect.Value, opts Options) error {
+	refType := ref.Type()
+	var agrErr AggregateError
+	for i := 0; i < refType.NumField(); i++ {
+		sf := refType.Field(i)
+		if !ref.Field(i).CanSet() {
+			continue
+		}
+		params, err := parseFieldParams(sf, opts)
+		if err != nil {
+			continue
+		}
+		if err := checkFieldConditions(ref, sf, params); err != nil {
+			opts.reportError(joinFieldPath(opts.fieldPath, sf.Name), err)
+			agrErr.Errors = append(agrErr.Errors, err)
+		}
+	}
+	if len(agrErr.Errors) == 0 {
+		return nil
+	}
+	return agrErr
+}
+
+func checkFieldConditions(ref reflect.Value, sf reflect.StructField, params FieldParams) error {
+	isSet := !ref.FieldByIndex(sf.Index).IsZero()
+
+	if params.RequiredIf != "" {
+		name, expected, _ := strings.Cut(params.RequiredIf, ":")
+		other, ok := siblingField(ref, name)
+		if !ok {
+			return newNoSupportedTagOptionError("required_if=" + params.RequiredIf)
+		}
+		value, _, _ := formatScalar(other)
+		if value == expected && !isSet {
+			return newRequiredIfError(sf.Name, params.Key, name, expected)
+		}
+	}
+
+	if params.RequiredWith != "" {
+		other, ok := siblingField(ref, params.RequiredWith)
+		if !ok {
+			return newNoSupportedTagOptionError("required_with=" + params.RequiredWith)
+		}
+		if !other.IsZero() && !isSet {
+			return newRequiredWithError(sf.Name, params.Key, params.RequiredWith)
+		}
+	}
+
+	if params.ExcludedWith != "" {
+		other, ok := siblingField(ref, params.ExcludedWith)
+		if !ok {
+			return newNoSupportedTagOptionError("excluded_with=" + params.ExcludedWith)
+		}
+		if !other.IsZero() && isSet {
+			return newExcludedWithError(sf.Name, params.Key, params.ExcludedWith)
+		}
+	}
+
+	return nil
+}
+
+// siblingField 只返回导出的字段，未导出的字段不能调用 Interface
+func siblingField(ref reflect.Value, name string) (reflect.Value, bool) {
+	sf, ok := ref.Type().FieldByName(name)
+	if !ok || len(sf.Index) != 1 || !sf.IsExported() {
+		return reflect.Value{}, false
+	}
+	return ref.Field(sf.Index[0]), true
+}
@@ -1,20 +1,23 @@
 package env
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
-func (opts *Options) getRawEnv(s string) string {
-	val := opts.rawEnvVars[s]
-	if val == "" {
-		val = opts.Environment[s]
+// filePath 把相对路径拼接到 BaseDir 上
+func (opts *Options) filePath(filename string) string {
+	if opts.BaseDir == "" || filepath.IsAbs(filename) || strings.HasPrefix(filename, "/") {
+		return filename
 	}
-	return os.Expand(val, opts.getRawEnv)
+	return filepath.Join(opts.BaseDir, filename)
 }
 
 func defaultOptions() Options {
@@ -22,9 +25,13 @@ func defaultOptions() Options {
 		TagName:             "env",
 		DefaultValueTagName: "envDefault",
 		PrefixTagName:       "envPrefix",
+		DescriptionTagName:  "envDescription",
+		ValidateTagName:     "envValidate",
+		SecretTagName:       "envSecret",
+		Context:             context.Background(),
 		Environment:         toMap(os.Environ()),
 		FuncMap:             defaultTypeParsers(),
-		rawEnvVars:          make(map[string]string),
+		rawEnvVars:          make(map[string]rawEnvVar),
 	}
 }
 
@@ -40,6 +47,10 @@ func mergeOptions(target, source *Options) {
 		if targetField.CanSet() && !isZero(sourceField) {
 			switch targetField.Kind() {
 			case reflect.Map:
+				// 默认选项中没有初始化的 map，比如 Resolvers
+				if targetField.IsNil() {
+					targetField.Set(reflect.MakeMap(targetField.Type()))
+				}
 				// 遍历 sourceFiled 的 map，将 sourceFiled 的每一项设置到 targetField
 				iter := sourceField.MapRange()
 				for iter.Next() {
@@ -65,23 +76,17 @@ func isZero(v reflect.Value) bool {
 // opt 是自定义的 options，如果自定义的 opt 没有对应的属性，就用默认的 defOptions
 func customOptions(opts Options) Options {
 	defOpts := defaultOptions()
+	// 设置了 Sources 时，环境变量只从 Sources 中读取
+	if len(opts.Sources) > 0 {
+		defOpts.Environment = make(map[string]string)
+	}
 	mergeOptions(&defOpts, &opts)
 	return defOpts
 }
 
 func optionsWithEnvPrefix(field reflect.StructField, opts Options) Options {
-	return Options{
-		Environment:           opts.Environment,
-		TagName:               opts.TagName,
-		PrefixTagName:         opts.PrefixTagName,
-		Prefix:                opts.Prefix + field.Tag.Get(opts.PrefixTagName),
-		DefaultValueTagName:   opts.DefaultValueTagName,
-		FuncMap:               opts.FuncMap,
-		rawEnvVars:            opts.rawEnvVars,
-		RequiredIfNoDef:       opts.RequiredIfNoDef,
-		UseFieldNameByDefault: opts.UseFieldNameByDefault,
-		OnSet:                 opts.OnSet,
-	}
+	opts.Prefix = opts.Prefix + field.Tag.Get(opts.PrefixTagName)
+	return opts
 }
 
 func defaultTypeParsers() map[reflect.Type]ParserFunc {
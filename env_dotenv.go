@@ -0,0 +1,204 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// LoadDotenv 读取一个或多个 .env 文件，返回可以直接交给 Options.Environment 的 map
+// 不传 paths 时读取当前目录下的 .env，后面的文件会覆盖前面文件中的同名 key
+func LoadDotenv(paths ...string) (map[string]string, error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	return loadDotenv(nil, paths)
+}
+
+// loadDotenv 和 LoadDotenv 一样，fsys 为 nil 时读取本地文件
+func loadDotenv(fsys fs.FS, paths []string) (map[string]string, error) {
+	result := make(map[string]string)
+	var agrErr AggregateError
+	for _, path := range paths {
+		data, err := getFromFile(fsys, path)
+		if err != nil {
+			agrErr.Errors = append(agrErr.Errors, err)
+			continue
+		}
+		values, err := parseDotenv(path, data)
+		if err != nil {
+			agrErr.Errors = append(agrErr.Errors, err.(AggregateError).Errors...)
+		}
+		for k, v := range values {
+			result[k] = v
+		}
+	}
+	if len(agrErr.Errors) != 0 {
+		return nil, agrErr
+	}
+	return result, nil
+}
+
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, newAggregateError(err)
+	}
+	return parseDotenv("", string(data))
+}
+
+// ParseWithDotenv 先读取 .env 文件，再解析 v
+// 优先级：opts.Environment > 进程环境变量 > .env 文件
+func ParseWithDotenv(v interface{}, opts Options, paths ...string) error {
+	values, err := LoadDotenv(paths...)
+	if err != nil {
+		return err
+	}
+	for k, val := range toMap(os.Environ()) {
+		values[k] = val
+	}
+	for k, val := range opts.Environment {
+		values[k] = val
+	}
+	opts.Environment = values
+	return ParseWithOptions(v, opts)
+}
+
+func parseDotenv(filename, data string) (map[string]string, error) {
+	result := make(map[string]string)
+	var agrErr AggregateError
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// export KEY=value
+		if rest := strings.TrimPrefix(line, "export"); rest != line && len(rest) > 0 && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimLeft(rest, " \t")
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			agrErr.Errors = append(agrErr.Errors, newDotenvSyntaxError(filename, lineNo, `missing "=" after key`))
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if !isValidDotenvKey(key) {
+			agrErr.Errors = append(agrErr.Errors, newDotenvSyntaxError(filename, lineNo, fmt.Sprintf("invalid key %q", key)))
+			continue
+		}
+
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			result[key] = unquotedDotenvValue(rest)
+			continue
+		}
+
+		// 引号中的值可以跨多行，一直读到匹配的引号为止
+		q := rest[0]
+		body := rest[1:]
+		end := closingQuote(body, q)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			body += "\n" + lines[i]
+			end = closingQuote(body, q)
+		}
+		if end < 0 {
+			agrErr.Errors = append(agrErr.Errors, newDotenvSyntaxError(filename, lineNo, "unterminated quoted value"))
+			continue
+		}
+		if tail := strings.TrimSpace(body[end+1:]); tail != "" && !strings.HasPrefix(tail, "#") {
+			agrErr.Errors = append(agrErr.Errors, newDotenvSyntaxError(filename, lineNo, "unexpected characters after closing quote"))
+			continue
+		}
+
+		value := body[:end]
+		if q == '"' {
+			var err error
+			if value, err = unescapeDotenv(value); err != nil {
+				agrErr.Errors = append(agrErr.Errors, newDotenvSyntaxError(filename, lineNo, err.Error()))
+				continue
+			}
+		}
+		result[key] = value
+	}
+
+	if len(agrErr.Errors) != 0 {
+		return nil, agrErr
+	}
+	return result, nil
+}
+
+func isValidDotenvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case i > 0 && (c == '.' || c >= '0' && c <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// "value # comment" => "value"
+func unquotedDotenvValue(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+func closingQuote(s string, q byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q == '"':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDotenv(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("invalid escape sequence %q", `\`)
+		}
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\', '$', '\'':
+			sb.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("invalid escape sequence %q", s[i-1:i+1])
+		}
+	}
+	return sb.String(), nil
+}
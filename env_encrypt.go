@@ -0,0 +1,114 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// EncryptedPrefix 是加密的值的前缀，后面是 base64 编码的 nonce 和密文
+// 这样的值会在 get 中用 Options 中的密钥解密，然后再读取文件或者解析
+//
+//	DB_PASSWORD=enc:v1:2Vx0...
+const EncryptedPrefix = "enc:v1:"
+
+// ErrNoDecryptionKey 表示值是加密的，但是 Options 中没有设置密钥
+var ErrNoDecryptionKey = errors.New("no decryption key")
+
+// GenerateKey 生成一个 AES-256 的密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt 用 AES-GCM 加密 plaintext，返回带有 EncryptedPrefix 的值，可以直接写到 .env 文件中
+// key 的长度必须是 16、24 或者 32 字节
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// decrypt 解密带有 EncryptedPrefix 的值
+// 返回的错误中不能带有密文和明文，所以不使用 base64 和 cipher 的原始错误
+func (opts *Options) decrypt(value string) (string, error) {
+	key, err := opts.decryptionKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", errors.New("invalid base64 in encrypted value")
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("message authentication failed, wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+// decryptionKey 按 DecryptionKey、DecryptionKeyFile、DecryptionKeyEnv 的顺序读取密钥
+// 文件和环境变量中的密钥是 base64 编码的
+func (opts *Options) decryptionKey() ([]byte, error) {
+	if len(opts.DecryptionKey) > 0 {
+		return opts.DecryptionKey, nil
+	}
+
+	var encoded string
+	switch {
+	case opts.DecryptionKeyFile != "":
+		content, err := getFromFile(opts.FS, opts.filePath(opts.DecryptionKeyFile))
+		if err != nil {
+			return nil, err
+		}
+		encoded = content
+	case opts.DecryptionKeyEnv != "":
+		value, ok := opts.Environment[opts.DecryptionKeyEnv]
+		if !ok {
+			return nil, newVarIsNotSetError(opts.DecryptionKeyEnv)
+		}
+		encoded = value
+	default:
+		return nil, ErrNoDecryptionKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("decryption key is not valid base64")
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -83,14 +83,23 @@ func (e EmptyVarError) Error() string {
 type LoadFileContentError struct {
 	Filename string
 	Key      string
-	Err      error
+	// 处理文件内容失败的步骤，比如 "base64"，读取文件失败时为空
+	Stage string
+	Err   error
 }
 
 func newLoadFileContentError(filename, key string, err error) error {
-	return LoadFileContentError{filename, key, err}
+	return LoadFileContentError{filename, key, "", err}
+}
+
+func newDecodeFileContentError(filename, key, stage string, err error) error {
+	return LoadFileContentError{filename, key, stage, err}
 }
 
 func (e LoadFileContentError) Error() string {
+	if e.Stage != "" {
+		return fmt.Sprintf("could not decode content of file %q from variable %s at stage %q: %v", e.Filename, e.Key, e.Stage, e.Err)
+	}
 	return fmt.Sprintf("could not load content of file %q from variable %s: %v", e.Filename, e.Key, e.Err)
 }
 
@@ -124,3 +133,277 @@ func newNoParserError(sf reflect.StructField) error {
 func (e NoParserError) Error() string {
 	return fmt.Sprintf("no parser found for field %q of type %q", e.Name, e.Type)
 }
+
+type DotenvSyntaxError struct {
+	Filename string
+	Line     int
+	Msg      string
+}
+
+func newDotenvSyntaxError(filename string, line int, msg string) error {
+	return DotenvSyntaxError{filename, line, msg}
+}
+
+func (e DotenvSyntaxError) Error() string {
+	if e.Filename == "" {
+		return fmt.Sprintf("dotenv syntax error on line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("dotenv syntax error in %s on line %d: %s", e.Filename, e.Line, e.Msg)
+}
+
+type LoadSourceError struct {
+	Source string
+	Err    error
+}
+
+func newLoadSourceError(source string, err error) error {
+	return LoadSourceError{source, err}
+}
+
+func (e LoadSourceError) Error() string {
+	return fmt.Sprintf("could not load source %q: %v", e.Source, e.Err)
+}
+
+func (e LoadSourceError) Unwrap() error {
+	return e.Err
+}
+
+type FormatError struct {
+	Name string
+	Type reflect.Type
+	Err  error
+}
+
+func newFormatError(sf reflect.StructField, err error) error {
+	return FormatError{sf.Name, sf.Type, err}
+}
+
+func (e FormatError) Error() string {
+	return fmt.Sprintf("format error on field %q of type %q: %v", e.Name, e.Type, e.Err)
+}
+
+type NoFormatterError struct {
+	Name string
+	Type reflect.Type
+}
+
+func newNoFormatterError(sf reflect.StructField) error {
+	return NoFormatterError{sf.Name, sf.Type}
+}
+
+func (e NoFormatterError) Error() string {
+	return fmt.Sprintf("no formatter found for field %q of type %q", e.Name, e.Type)
+}
+
+type ValidationError struct {
+	Field string
+	Key   string
+	Rule  string
+	Value string
+}
+
+func newValidationError(field, key, rule, value string) error {
+	return ValidationError{field, key, rule, value}
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("validation failed on field %q from variable %s: value %q does not satisfy %q", e.Field, e.Key, e.Value, e.Rule)
+}
+
+// This error occurs when the Validate or EnvParsed method of a struct fails.
+type HookError struct {
+	Path string
+	Type reflect.Type
+	Hook string
+	Err  error
+}
+
+func newHookError(path string, typee reflect.Type, hook string, err error) error {
+	return HookError{path, typee, hook, err}
+}
+
+func (e HookError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s of type %q failed: %v", e.Hook, e.Type, e.Err)
+	}
+	return fmt.Sprintf("%s of field %q of type %q failed: %v", e.Hook, e.Path, e.Type, e.Err)
+}
+
+func (e HookError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredIfError struct {
+	Field string
+	Key   string
+	Other string
+	Value string
+}
+
+func newRequiredIfError(field, key, other, value string) error {
+	return RequiredIfError{field, key, other, value}
+}
+
+func (e RequiredIfError) Error() string {
+	return fmt.Sprintf("field %q from variable %s is required when field %q is %q", e.Field, e.Key, e.Other, e.Value)
+}
+
+type RequiredWithError struct {
+	Field string
+	Key   string
+	Other string
+}
+
+func newRequiredWithError(field, key, other string) error {
+	return RequiredWithError{field, key, other}
+}
+
+func (e RequiredWithError) Error() string {
+	return fmt.Sprintf("field %q from variable %s is required when field %q is set", e.Field, e.Key, e.Other)
+}
+
+type ExcludedWithError struct {
+	Field string
+	Key   string
+	Other string
+}
+
+func newExcludedWithError(field, key, other string) error {
+	return ExcludedWithError{field, key, other}
+}
+
+func (e ExcludedWithError) Error() string {
+	return fmt.Sprintf("field %q from variable %s must not be set when field %q is set", e.Field, e.Key, e.Other)
+}
+
+// This error occurs when both KEY and KEY_FILE are set.
+type FileSuffixConflictError struct {
+	Key     string
+	FileKey string
+}
+
+func newFileSuffixConflictError(key, fileKey string) error {
+	return FileSuffixConflictError{key, fileKey}
+}
+
+func (e FileSuffixConflictError) Error() string {
+	return fmt.Sprintf("environment variables %q and %q are both set", e.Key, e.FileKey)
+}
+
+// This error occurs when expanding a variable references itself, directly or through other variables.
+type ExpandCycleError struct {
+	Key   string
+	Chain []string
+}
+
+func newExpandCycleError(key string, chain []string) error {
+	return ExpandCycleError{key, chain}
+}
+
+func (e ExpandCycleError) Error() string {
+	return fmt.Sprintf("expanding variable %q: reference cycle %s", e.Key, strings.Join(e.Chain, " -> "))
+}
+
+// This error occurs when a variable referenced with ${NAME:?message} is unset or empty.
+type ExpandUnsetError struct {
+	Key     string
+	Name    string
+	Message string
+}
+
+func newExpandUnsetError(key, name, message string) error {
+	return ExpandUnsetError{key, name, message}
+}
+
+func (e ExpandUnsetError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("expanding variable %q: %s is not set", e.Key, e.Name)
+	}
+	return fmt.Sprintf("expanding variable %q: %s: %s", e.Key, e.Name, e.Message)
+}
+
+// This error occurs when a value contains an invalid ${...} expression.
+// The value itself is not part of the message, because it may be a secret.
+type ExpandSyntaxError struct {
+	Key string
+	Msg string
+}
+
+func newExpandSyntaxError(key, msg string) error {
+	return ExpandSyntaxError{key, msg}
+}
+
+func (e ExpandSyntaxError) Error() string {
+	return fmt.Sprintf("expanding variable %q: %s", e.Key, e.Msg)
+}
+
+// This error occurs when a default value references a variable that is neither a field nor an environment variable.
+type UnknownReferenceError struct {
+	Key  string
+	Name string
+}
+
+func newUnknownReferenceError(key, name string) error {
+	return UnknownReferenceError{key, name}
+}
+
+func (e UnknownReferenceError) Error() string {
+	return fmt.Sprintf("expanding variable %q: default value references unknown variable %s", e.Key, e.Name)
+}
+
+// This error occurs when a resolver fails to dereference a value like "file:///run/secrets/token".
+type ResolveError struct {
+	Key    string
+	Scheme string
+	Err    error
+}
+
+func newResolveError(key, scheme string, err error) error {
+	return ResolveError{key, scheme, err}
+}
+
+func (e ResolveError) Error() string {
+	return fmt.Sprintf("could not resolve variable %s with scheme %q: %v", e.Key, e.Scheme, e.Err)
+}
+
+func (e ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// This error occurs when a SecretProvider fails to return the value referenced by envSecret.
+type SecretProviderError struct {
+	Key string
+	Ref string
+	Err error
+}
+
+func newSecretProviderError(key, ref string, err error) error {
+	return SecretProviderError{key, ref, err}
+}
+
+func (e SecretProviderError) Error() string {
+	return fmt.Sprintf("could not get secret %q for variable %s: %v", e.Ref, e.Key, e.Err)
+}
+
+func (e SecretProviderError) Unwrap() error {
+	return e.Err
+}
+
+// This error occurs when an "enc:v1:" value cannot be decrypted.
+// Neither the ciphertext nor the plaintext is part of the message.
+type DecryptError struct {
+	Key string
+	Err error
+}
+
+func newDecryptError(key string, err error) error {
+	return DecryptError{key, err}
+}
+
+func (e DecryptError) Error() string {
+	return fmt.Sprintf("could not decrypt variable %s: %v", e.Key, e.Err)
+}
+
+func (e DecryptError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WriteExample 根据结构体的 tag 生成 .env.example，每个 key 上面用注释写明说明、类型和选项
+//
+//	# Database host
+//	# type: string, required
+//	DB_HOST=localhost
+func WriteExample(w io.Writer, v interface{}) error {
+	return WriteExampleWithOptions(w, v, Options{})
+}
+
+func WriteExampleWithOptions(w io.Writer, v interface{}, opts Options) error {
+	var sb strings.Builder
+	err := parseInternal(
+		v,
+		func(_ reflect.Value, refTypeField reflect.StructField, _ Options, fieldParams FieldParams) error {
+			if fieldParams.OwnKey == "" {
+				return nil
+			}
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			writeExampleField(&sb, refTypeField, fieldParams)
+			return nil
+		},
+		exampleOptions(opts),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// exampleOptions 让 []struct 和 map[string]struct 的字段输出 DB_<n>_HOST、TENANT_<name>_URL 这样的 key
+func exampleOptions(opts Options) Options {
+	o := customOptions(opts)
+	o.example = true
+	return o
+}
+
+func writeExampleField(sb *strings.Builder, sf reflect.StructField, fieldParams FieldParams) {
+	for _, line := range strings.Split(fieldParams.Description, "\n") {
+		if line != "" {
+			sb.WriteString("# " + line + "\n")
+		}
+	}
+
+	info := []string{"type: " + sf.Type.String()}
+	if len(fieldParams.Aliases) > 0 {
+		info = append(info, "aliases: "+strings.Join(fieldParams.Aliases, "|"))
+	}
+	if fieldParams.Deprecated {
+		info = append(info, "deprecated")
+	}
+	if fieldParams.Required {
+		info = append(info, "required")
+	}
+	if fieldParams.NotEmpty {
+		info = append(info, "notEmpty")
+	}
+	if fieldParams.LoadFile {
+		info = append(info, "file")
+	}
+	if fieldParams.Expand {
+		info = append(info, "expand")
+	}
+	if fieldParams.Unset {
+		info = append(info, "unset")
+	}
+	if fieldParams.Resolve {
+		info = append(info, "resolve")
+	}
+	if fieldParams.Scan {
+		info = append(info, "scan")
+	}
+	if fieldParams.Secret {
+		info = append(info, "secret")
+	}
+	if fieldParams.SecretRef != "" {
+		info = append(info, "envSecret: "+fieldParams.SecretRef)
+	}
+	if fieldParams.RequiredIf != "" {
+		info = append(info, "required_if: "+fieldParams.RequiredIf)
+	}
+	if fieldParams.RequiredWith != "" {
+		info = append(info, "required_with: "+fieldParams.RequiredWith)
+	}
+	if fieldParams.ExcludedWith != "" {
+		info = append(info, "excluded_with: "+fieldParams.ExcludedWith)
+	}
+	if fieldParams.Validate != "" {
+		info = append(info, "validate: "+fieldParams.Validate)
+	}
+	sb.WriteString("# " + strings.Join(info, ", ") + "\n")
+
+	sb.WriteString(fieldParams.Key + "=" + dotenvQuote(fieldParams.DefaultValue) + "\n")
+}
+
+// dotenvQuote 在需要时给值加上双引号，保证 LoadDotenv 能读回同样的值
+func dotenvQuote(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\r\n#\"'\\") {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return fmt.Sprintf(`"%s"`, r.Replace(value))
+}
@@ -0,0 +1,215 @@
+package env
+
+import (
+	"slices"
+	"strings"
+)
+
+// expander 实现 shell 风格的变量展开
+//
+//	$NAME、${NAME}       变量的值
+//	${NAME:-word}        NAME 没有设置或者为空时使用 word
+//	${NAME:=word}        同上，并且把 word 赋值给 NAME，后面的引用也能拿到
+//	${NAME:?message}     NAME 没有设置或者为空时返回 ExpandUnsetError
+//	${NAME:+word}        NAME 有值时使用 word，否则为空
+//	$$                   输出 $
+//
+// 去掉冒号时（比如 ${NAME-word}）只判断是否设置，不判断是否为空
+// 引用的变量的值和 word 都会继续展开，chain 记录正在展开的变量，用来发现循环引用
+// 引用的变量按需展开，相当于按照依赖关系的拓扑顺序解析，和字段声明的顺序无关
+type expander struct {
+	opts  *Options
+	key   string
+	chain []string
+	// isDefault 表示正在展开的是 envDefault 中的值，这时不能引用不存在的变量
+	isDefault bool
+	// secret 表示引用了加密的变量，展开后的值要和 secret 字段一样处理
+	secret bool
+}
+
+// rawEnvVar 是字段展开前的值，key 是字段完整的 key
+type rawEnvVar struct {
+	value     string
+	exists    bool
+	isDefault bool
+}
+
+// expand 展开 key 对应的值 value，引用了加密的变量时 secret 为 true
+func (opts *Options) expand(key, value string, isDefault bool) (result string, secret bool, err error) {
+	e := expander{opts: opts, key: key, chain: []string{key}, isDefault: isDefault}
+	result, err = e.expand(value)
+	return result, e.secret, err
+}
+
+func (e *expander) expand(s string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		switch c := s[i+1]; {
+		case c == '$':
+			sb.WriteByte('$')
+			i++
+		case c == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", newExpandSyntaxError(e.key, `missing "}"`)
+			}
+			value, err := e.expandBraced(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+			i = end
+		case isNameStart(c):
+			j := i + 2
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			value, err := e.reference(s[i+1 : j])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(value)
+			i = j - 1
+		default:
+			// 和 shell 一样，$ 后面不是变量名时原样输出
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String(), nil
+}
+
+// expandBraced 处理 ${...} 中的内容
+func (e *expander) expandBraced(body string) (string, error) {
+	n := 0
+	for n < len(body) && (isNameChar(body[n]) && (n > 0 || isNameStart(body[n]))) {
+		n++
+	}
+	if n == 0 {
+		return "", newExpandSyntaxError(e.key, "bad substitution: invalid variable name")
+	}
+	name, rest := body[:n], body[n:]
+
+	if rest == "" {
+		return e.reference(name)
+	}
+	value, exists, err := e.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	checkEmpty := strings.HasPrefix(rest, ":")
+	if checkEmpty {
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return "", newExpandSyntaxError(e.key, "bad substitution: missing operator after "+name)
+	}
+	op, word := rest[0], rest[1:]
+	missing := !exists || (checkEmpty && value == "")
+
+	switch op {
+	case '-':
+		if missing {
+			return e.expand(word)
+		}
+		return value, nil
+	case '=':
+		if missing {
+			// 保存未展开的 word，和其他变量一样在引用时展开
+			e.opts.rawEnvVars[name] = rawEnvVar{word, true, e.isDefault}
+			return e.expand(word)
+		}
+		return value, nil
+	case '?':
+		if missing {
+			message, err := e.expand(word)
+			if err != nil {
+				return "", err
+			}
+			return "", newExpandUnsetError(e.key, name, message)
+		}
+		return value, nil
+	case '+':
+		if missing {
+			return "", nil
+		}
+		return e.expand(word)
+	}
+	return "", newExpandSyntaxError(e.key, "bad substitution: unknown operator "+string(op)+" after "+name)
+}
+
+// reference 处理没有操作符的引用，envDefault 中不能引用既不是字段也不是环境变量的变量
+func (e *expander) reference(name string) (string, error) {
+	value, exists, err := e.lookup(name)
+	if _, isField := e.opts.rawEnvVars[name]; err == nil && !exists && !isField && e.isDefault {
+		return "", newUnknownReferenceError(e.key, name)
+	}
+	return value, err
+}
+
+// lookup 返回变量展开后的值，优先使用字段的值，然后是环境变量
+// 和 get 一样，加密的值先解密再展开
+func (e *expander) lookup(name string) (string, bool, error) {
+	if slices.Contains(e.chain, name) {
+		return "", false, newExpandCycleError(e.key, append(slices.Clone(e.chain), name))
+	}
+
+	raw, ok := e.opts.rawEnvVars[name]
+	if !ok {
+		raw.value, raw.exists = e.opts.Environment[name]
+	}
+	if raw.exists && isEncrypted(raw.value) {
+		value, err := e.opts.decrypt(raw.value)
+		if err != nil {
+			return "", false, newDecryptError(name, err)
+		}
+		raw.value = value
+		e.secret = true
+	}
+	if !raw.exists || !strings.Contains(raw.value, "$") {
+		return raw.value, raw.exists, nil
+	}
+
+	isDefault := e.isDefault
+	e.chain = append(e.chain, name)
+	e.isDefault = raw.isDefault
+	defer func() {
+		e.chain = e.chain[:len(e.chain)-1]
+		e.isDefault = isDefault
+	}()
+	value, err := e.expand(raw.value)
+	return value, true, err
+}
+
+// closingBrace 返回和 start 之前的 "${" 匹配的 "}" 的位置，嵌套的 "${...}" 会被跳过
+func closingBrace(s string, start int) int {
+	depth := 1
+	for j := start; j < len(s); j++ {
+		switch {
+		case s[j] == '$' && j+1 < len(s) && (s[j+1] == '$' || s[j+1] == '{'):
+			if s[j+1] == '{' {
+				depth++
+			}
+			j++
+		case s[j] == '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
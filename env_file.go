@@ -0,0 +1,36 @@
+package env
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// fileStages 是 envFilePipeline 支持的步骤，按照 tag 中的顺序依次处理文件内容
+//
+//	Token string `env:"TOKEN,file" envFilePipeline:"base64,gzip,trim"`
+var fileStages = map[string]func(string) (string, error){
+	"trim": func(v string) (string, error) {
+		return strings.TrimSpace(v), nil
+	},
+	"base64": func(v string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+		return string(b), err
+	},
+	"hex": func(v string) (string, error) {
+		b, err := hex.DecodeString(strings.TrimSpace(v))
+		return string(b), err
+	},
+	"gzip": func(v string) (string, error) {
+		r, err := gzip.NewReader(bytes.NewReader([]byte(v)))
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		return string(b), err
+	},
+}
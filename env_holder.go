@@ -0,0 +1,187 @@
+package env
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type SubscribeFn[T any] func(old, new T)
+
+// Holder 保存最近一次解析成功的配置，可以在运行时重新解析
+// 解析或校验失败时保留之前的配置
+type Holder[T any] struct {
+	opts  Options
+	value atomic.Pointer[T]
+	// reloadMu 保证 Reload 和回调按顺序执行，回调执行时不持有 mu，可以在回调里订阅和取消订阅
+	reloadMu sync.Mutex
+	mu       sync.Mutex
+	files    map[string]fileStamp
+	nextID   int
+	subs     map[int]SubscribeFn[T]
+	onError  func(error)
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewHolder[T any](opts Options) (*Holder[T], error) {
+	h := &Holder[T]{
+		opts: opts,
+		subs: make(map[int]SubscribeFn[T]),
+	}
+	t, files, err := h.parse()
+	if err != nil {
+		return nil, err
+	}
+	h.value.Store(&t)
+	h.files = files
+	return h, nil
+}
+
+func (h *Holder[T]) Get() T {
+	return *h.value.Load()
+}
+
+// Subscribe 注册配置变化的回调，返回的函数用来取消订阅
+// 回调在 Reload 中同步执行，可以在回调里调用 Subscribe 和返回的函数，但不能调用 Reload
+func (h *Holder[T]) Subscribe(fn SubscribeFn[T]) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.nextID
+	h.nextID++
+	h.subs[id] = fn
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, id)
+	}
+}
+
+// OnError 设置后台重新加载失败时的回调
+func (h *Holder[T]) OnError(fn func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onError = fn
+}
+
+// Reload 重新解析配置，成功后替换当前配置，配置有变化时通知订阅者
+func (h *Holder[T]) Reload() error {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	h.mu.Lock()
+	t, files, err := h.parse()
+	if err != nil {
+		h.mu.Unlock()
+		return err
+	}
+	h.files = files
+
+	old := h.value.Swap(&t)
+	if reflect.DeepEqual(*old, t) {
+		h.mu.Unlock()
+		return nil
+	}
+	subs := make([]SubscribeFn[T], 0, len(h.subs))
+	for _, fn := range h.subs {
+		subs = append(subs, fn)
+	}
+	h.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(*old, t)
+	}
+	return nil
+}
+
+// WatchSignals 收到信号时重新加载配置，默认监听 SIGHUP，ctx 结束后停止
+func (h *Holder[T]) WatchSignals(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				h.reloadInBackground()
+			}
+		}
+	}()
+}
+
+// WatchFiles 每隔 interval 检查一次 file 选项读取的文件，文件变化时重新加载配置
+func (h *Holder[T]) WatchFiles(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if h.filesChanged() {
+					h.reloadInBackground()
+				}
+			}
+		}
+	}()
+}
+
+func (h *Holder[T]) reloadInBackground() {
+	if err := h.Reload(); err != nil {
+		h.mu.Lock()
+		onError := h.onError
+		h.mu.Unlock()
+		if onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func (h *Holder[T]) filesChanged() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for filename, stamp := range h.files {
+		if h.stampFile(filename) != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// parse 解析配置，同时记录 file 选项读取了哪些文件
+func (h *Holder[T]) parse() (T, map[string]fileStamp, error) {
+	var t T
+	report, err := ParseWithReport(&t, h.opts)
+	if err != nil {
+		return t, nil, err
+	}
+	files := make(map[string]fileStamp)
+	for _, field := range report.Fields {
+		if field.File != "" {
+			files[field.File] = h.stampFile(field.File)
+		}
+	}
+	return t, files, nil
+}
+
+func (h *Holder[T]) stampFile(filename string) fileStamp {
+	info, err := statFile(h.opts.FS, filename)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{info.ModTime(), info.Size()}
+}
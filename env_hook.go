@@ -0,0 +1,28 @@
+package env
+
+import "reflect"
+
+// runHooks 在结构体（包括嵌套的结构体）的字段解析完成后依次调用 EnvParsed 和 Validate
+func runHooks(ref reflect.Value, opts Options) error {
+	if !ref.CanAddr() {
+		return nil
+	}
+
+	var agrErr AggregateError
+	v := ref.Addr().Interface()
+	if hook, ok := v.(ParsedHook); ok {
+		if err := hook.EnvParsed(); err != nil {
+			agrErr.Errors = append(agrErr.Errors, newHookError(opts.fieldPath, ref.Type(), "EnvParsed", err))
+		}
+	}
+	if validator, ok := v.(Validator); ok && len(agrErr.Errors) == 0 {
+		if err := validator.Validate(); err != nil {
+			agrErr.Errors = append(agrErr.Errors, newHookError(opts.fieldPath, ref.Type(), "Validate", err))
+		}
+	}
+
+	if len(agrErr.Errors) == 0 {
+		return nil
+	}
+	return agrErr
+}
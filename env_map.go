@@ -0,0 +1,233 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func isStructMap(typee reflect.Type) bool {
+	if typee.Kind() != reflect.Map {
+		return false
+	}
+	elem := typee.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// doParseStructMap 解析 map[string]struct 类型的字段，map 的 key 是 envPrefix 和字段 key 中间的部分
+//
+//	type Config struct {
+//		Tenants map[string]Tenant `envPrefix:"TENANT_"`
+//	}
+//
+// TENANT_ACME_URL=a TENANT_GLOBEX_URL=b => Tenants["ACME"].URL=a Tenants["GLOBEX"].URL=b
+func doParseStructMap(refField reflect.Value, refTypeField reflect.StructField, processField processFieldFn, opts Options) error {
+	prefix := opts.Prefix + refTypeField.Tag.Get(opts.PrefixTagName)
+	mapType := refField.Type()
+	elemType := mapType.Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	keyParserFunc, ok := getParserFunc(opts.FuncMap, mapType.Key())
+	if !ok {
+		return newNoParserError(refTypeField)
+	}
+
+	if opts.example {
+		return doParsePlaceholder(elemType, processField, opts, prefix, "<name>")
+	}
+
+	// 只有真正解析时才从环境变量中找 key，已有的 key 也要处理，这样 Marshal 才能输出 map 中的内容
+	segments := make(map[string]bool)
+	if opts.parsing {
+		segments = structMapSegments(prefix, structKeys(structType, opts), opts.Environment)
+	}
+	iter := refField.MapRange()
+	for iter.Next() {
+		segment, _, _ := formatScalar(iter.Key())
+		segments[segment] = true
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(segments))
+	for segment := range segments {
+		names = append(names, segment)
+	}
+	sort.Strings(names)
+
+	result := reflect.MakeMapWithSize(mapType, len(names))
+	var agrErr AggregateError
+	for _, segment := range names {
+		k, err := keyParserFunc(segment)
+		if err != nil {
+			agrErr.Errors = append(agrErr.Errors, newParseError(refTypeField, err))
+			continue
+		}
+		key := reflect.ValueOf(k).Convert(mapType.Key())
+
+		// map 中的值不能取地址，需要复制出来解析后再放回去
+		elem := reflect.New(structType)
+		if existing := refField.MapIndex(key); existing.IsValid() {
+			if existing.Kind() == reflect.Ptr {
+				if !existing.IsNil() {
+					elem = existing
+				}
+			} else {
+				elem.Elem().Set(existing)
+			}
+		}
+
+		elemOpts := opts
+		elemOpts.Prefix = prefix + segment + "_"
+		elemOpts.fieldPath = fmt.Sprintf("%s[%s]", opts.fieldPath, segment)
+		elemOpts.indexed = true
+		if err := doParse(elem.Elem(), processField, elemOpts); err != nil {
+			var val AggregateError
+			if errors.As(err, &val) {
+				agrErr.Errors = append(agrErr.Errors, val.Errors...)
+			} else {
+				agrErr.Errors = append(agrErr.Errors, err)
+			}
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			result.SetMapIndex(key, elem)
+		} else {
+			result.SetMapIndex(key, elem.Elem())
+		}
+	}
+
+	refField.Set(result)
+	if len(agrErr.Errors) == 0 {
+		return nil
+	}
+	return agrErr
+}
+
+// structKeys 返回结构体中所有字段可以使用的 key（不带前缀），包括别名和 FileSuffix 对应的 KEY_FILE
+func structKeys(typee reflect.Type, opts Options) []string {
+	var keys []string
+	keyOpts := opts
+	keyOpts.Prefix = ""
+	keyOpts.Environment = nil
+	keyOpts.callHooks = false
+	_ = doParse(reflect.New(typee).Elem(), func(_ reflect.Value, _ reflect.StructField, _ Options, fieldParams FieldParams) error {
+		if fieldParams.OwnKey == "" {
+			return nil
+		}
+		for _, key := range fieldParams.Keys() {
+			keys = append(keys, key)
+			if opts.FileSuffix != "" && !fieldParams.LoadFile {
+				keys = append(keys, key+opts.FileSuffix)
+			}
+		}
+		return nil
+	}, keyOpts)
+	return keys
+}
+
+// structMapSegments 找出 prefix 和字段 key 中间的部分
+// 有多个字段 key 可以匹配时，使用最长的字段 key，比如 TENANT_A_DB_URL 匹配 DB_URL 而不是 URL
+func structMapSegments(prefix string, keys []string, env map[string]string) map[string]bool {
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+
+	segments := make(map[string]bool)
+	for envKey := range env {
+		rest, ok := strings.CutPrefix(envKey, prefix)
+		if !ok {
+			continue
+		}
+		for _, key := range keys {
+			if segment, ok := strings.CutSuffix(rest, "_"+key); ok && segment != "" {
+				segments[segment] = true
+				break
+			}
+		}
+	}
+	return segments
+}
+
+// setScannedMap 用所有以 key 开头的环境变量填充 map，去掉前缀的部分作为 map 的 key
+//
+//	Labels map[string]string `env:"LABEL_,scan" envKeyCase:"lower"`
+//
+// LABEL_TEAM=core LABEL_ENV=prod => {"team": "core", "env": "prod"}
+func setScannedMap(refField reflect.Value, refTypeField reflect.StructField, fieldParams FieldParams, opts Options) error {
+	if refField.Kind() != reflect.Map || fieldParams.OwnKey == "" {
+		return newNoParserError(refTypeField)
+	}
+
+	keyCase := refTypeField.Tag.Get("envKeyCase")
+	switch keyCase {
+	case "", "lower", "upper":
+	default:
+		return newNoSupportedTagOptionError("envKeyCase:" + keyCase)
+	}
+
+	envKeys := make([]string, 0)
+	for envKey := range opts.Environment {
+		if strings.HasPrefix(envKey, fieldParams.Key) && envKey != fieldParams.Key {
+			envKeys = append(envKeys, envKey)
+		}
+	}
+	sort.Strings(envKeys)
+
+	if len(envKeys) == 0 {
+		if fieldParams.Required {
+			return newVarIsNotSetError(fieldParams.Key + "*")
+		}
+		if fieldParams.NotEmpty {
+			return newEmptyVarError(fieldParams.Key + "*")
+		}
+		return nil
+	}
+
+	entries := make([][2]string, 0, len(envKeys))
+	values := make(map[string]string, len(envKeys))
+	for _, envKey := range envKeys {
+		name := strings.TrimPrefix(envKey, fieldParams.Key)
+		switch keyCase {
+		case "lower":
+			name = strings.ToLower(name)
+		case "upper":
+			name = strings.ToUpper(name)
+		}
+		entries = append(entries, [2]string{name, opts.Environment[envKey]})
+		values[name] = opts.Environment[envKey]
+
+		if fieldParams.Unset {
+			defer os.Unsetenv(envKey)
+		}
+	}
+
+	secrets := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		secrets = append(secrets, secretParts(refTypeField, entry[1])...)
+	}
+	if err := setMap(refField, refTypeField, entries, opts.FuncMap); err != nil {
+		return redactError(err, fieldParams, secrets...)
+	}
+
+	if opts.OnSet != nil {
+		if fieldParams.Secret {
+			for name := range values {
+				values[name] = Redacted
+			}
+		}
+		opts.OnSet(fieldParams.Key, values, false)
+	}
+
+	return redactError(validate(refField, refTypeField, fieldParams), fieldParams, secrets...)
+}
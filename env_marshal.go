@@ -0,0 +1,236 @@
+package env
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal 是 Parse 的逆操作，按照同样的 tag 把结构体转换成环境变量
+// 带有 file 选项的字段会被跳过，因为无法还原出文件名
+// 空的 slice 和 map 也会被跳过，再次解析时得到的是 nil
+// 带有 envDefault 的字段值为空时仍然输出空字符串，但是再次解析时会得到默认值，这种字段无法还原
+func Marshal(v interface{}) (map[string]string, error) {
+	return MarshalWithOptions(v, Options{})
+}
+
+func MarshalWithOptions(v interface{}, opts Options) (map[string]string, error) {
+	ref := reflect.ValueOf(v)
+	if ref.Kind() == reflect.Ptr && !ref.IsNil() {
+		ref = ref.Elem()
+	}
+	if ref.Kind() != reflect.Struct {
+		return nil, newAggregateError(NotStructPtrError{})
+	}
+
+	// 复制一份，避免 init 选项修改传入的结构体
+	ptr := reflect.New(ref.Type())
+	ptr.Elem().Set(ref)
+
+	result := make(map[string]string)
+	if err := parseInternal(ptr.Interface(), marshalField(result), customOptions(opts)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func marshalField(result map[string]string) processFieldFn {
+	return func(refField reflect.Value, refTypeField reflect.StructField, _ Options, fieldParams FieldParams) error {
+		if fieldParams.OwnKey == "" || fieldParams.LoadFile {
+			return nil
+		}
+		if refField.Kind() == reflect.Ptr && refField.IsNil() {
+			return nil
+		}
+		// 空的 slice 和 map 输出空字符串再解析会得到 nil，不如直接跳过
+		if (refField.Kind() == reflect.Slice || refField.Kind() == reflect.Map) && refField.Len() == 0 {
+			return nil
+		}
+		// 没有设置的 Optional 不输出，这样再次解析时结果不变
+		if p, ok := refField.Interface().(presencer); ok {
+			set, empty, isDefault := p.presence()
+			if !set || (isDefault && !empty) {
+				return nil
+			}
+			if empty {
+				result[fieldParams.Key] = ""
+				return nil
+			}
+		}
+		if fieldParams.Scan && refField.Kind() == reflect.Map {
+			return formatScannedMap(result, refField, refTypeField, fieldParams)
+		}
+		value, err := format(refField, refTypeField)
+		if err != nil {
+			return err
+		}
+		result[fieldParams.Key] = value
+		return nil
+	}
+}
+
+func format(field reflect.Value, sf reflect.StructField) (string, error) {
+	value, ok, err := formatScalar(field)
+	if err != nil {
+		return "", newFormatError(sf, err)
+	}
+	if ok {
+		return value, nil
+	}
+
+	switch field.Kind() {
+	case reflect.Slice:
+		return formatSlice(field, sf)
+	case reflect.Map:
+		return formatMap(field, sf)
+	}
+
+	return "", newNoFormatterError(sf)
+}
+
+func formatSlice(field reflect.Value, sf reflect.StructField) (string, error) {
+	separator := sf.Tag.Get("envSeparator")
+	if separator == "" {
+		separator = ","
+	}
+
+	parts := make([]string, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		part, ok, err := formatScalar(field.Index(i))
+		if err != nil {
+			return "", newFormatError(sf, err)
+		}
+		if !ok {
+			return "", newNoFormatterError(sf)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func formatMap(field reflect.Value, sf reflect.StructField) (string, error) {
+	separator := sf.Tag.Get("envSeparator")
+	if separator == "" {
+		separator = ","
+	}
+
+	keyValSeparator := sf.Tag.Get("envKeyValSeparator")
+	if keyValSeparator == "" {
+		keyValSeparator = ":"
+	}
+
+	parts := make([]string, 0, field.Len())
+	iter := field.MapRange()
+	for iter.Next() {
+		key, ok, err := formatScalar(iter.Key())
+		if err != nil {
+			return "", newFormatError(sf, err)
+		}
+		if !ok {
+			return "", newNoFormatterError(sf)
+		}
+		elem, ok, err := formatScalar(iter.Value())
+		if err != nil {
+			return "", newFormatError(sf, err)
+		}
+		if !ok {
+			return "", newNoFormatterError(sf)
+		}
+		parts = append(parts, key+keyValSeparator+elem)
+	}
+	// map 是无序的，排序后输出才能稳定
+	sort.Strings(parts)
+	return strings.Join(parts, separator), nil
+}
+
+// formatScannedMap 把 scan 选项的 map 还原成多个环境变量，map 的 key 接在前缀后面
+func formatScannedMap(result map[string]string, field reflect.Value, sf reflect.StructField, fieldParams FieldParams) error {
+	iter := field.MapRange()
+	for iter.Next() {
+		key, ok, err := formatScalar(iter.Key())
+		if err != nil {
+			return newFormatError(sf, err)
+		}
+		if !ok {
+			return newNoFormatterError(sf)
+		}
+		elem, ok, err := formatScalar(iter.Value())
+		if err != nil {
+			return newFormatError(sf, err)
+		}
+		if !ok {
+			return newNoFormatterError(sf)
+		}
+		result[fieldParams.Key+key] = elem
+	}
+	return nil
+}
+
+// formatScalar 把单个值转换成字符串，第二个返回值表示是否支持这个类型
+func formatScalar(v reflect.Value) (string, bool, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", true, nil
+		}
+		v = v.Elem()
+	}
+
+	// Secret 的 MarshalText 只会返回 Redacted，这里需要真实的值
+	if r, ok := v.Interface().(revealer); ok {
+		return formatScalar(reflect.ValueOf(r.reveal()))
+	}
+
+	// 和 set 一样，优先使用 TextMarshaler
+	if tm := asTextMarshaler(v); tm != nil {
+		b, err := tm.MarshalText()
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), true, nil
+	}
+
+	switch val := v.Interface().(type) {
+	case time.Duration:
+		return val.String(), true, nil
+	case url.URL:
+		return val.String(), true, nil
+	case time.Location:
+		return val.String(), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true, nil
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), true, nil
+	}
+	return "", false, nil
+}
+
+func asTextMarshaler(v reflect.Value) encoding.TextMarshaler {
+	// 复制一份再取地址，这样指针接收者的 MarshalText 也能被调用
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	tm, ok := ptr.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return nil
+	}
+	return tm
+}
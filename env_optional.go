@@ -0,0 +1,116 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Optional 记录环境变量是否存在、是否为空、是否使用了默认值，解析方式和 T 一样
+// 用来区分“没有设置”和“设置成空字符串”，不需要再用指针或者自己调用 os.LookupEnv
+//
+//	type Config struct {
+//		Proxy env.Optional[string] `env:"HTTP_PROXY"`
+//	}
+//
+//	if !cfg.Proxy.IsSet() { ... }      // 没有设置 HTTP_PROXY
+//	if cfg.Proxy.IsEmpty() { ... }     // HTTP_PROXY=
+//	proxy, ok := cfg.Proxy.Get()       // 有值时 ok 为 true
+type Optional[T any] struct {
+	value     T
+	hasValue  bool
+	set       bool
+	empty     bool
+	isDefault bool
+}
+
+// Some 返回一个已经设置了值的 Optional
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, hasValue: true, set: true}
+}
+
+// Get 返回解析后的值，环境变量和默认值都没有提供值时 ok 为 false
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.hasValue
+}
+
+// OrElse 在没有值时返回 def
+func (o Optional[T]) OrElse(def T) T {
+	if !o.hasValue {
+		return def
+	}
+	return o.value
+}
+
+// IsSet 表示环境变量是否存在，值为空字符串也算存在
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsEmpty 表示环境变量存在但是值为空字符串
+func (o Optional[T]) IsEmpty() bool {
+	return o.empty
+}
+
+// IsDefault 表示使用了 envDefault 中的值
+func (o Optional[T]) IsDefault() bool {
+	return o.isDefault
+}
+
+func (o Optional[T]) String() string {
+	if !o.hasValue {
+		return ""
+	}
+	return fmt.Sprint(o.value)
+}
+
+func (o *Optional[T]) setInner(value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error {
+	if err := setInner(&o.value, value, sf, funcMap); err != nil {
+		return err
+	}
+	o.hasValue = true
+	return nil
+}
+
+func (o *Optional[T]) setPresence(set, empty, isDefault bool) {
+	o.set, o.empty, o.isDefault = set, empty, isDefault
+}
+
+func (o Optional[T]) reveal() interface{} {
+	return o.value
+}
+
+func (o Optional[T]) presence() (set, empty, isDefault bool) {
+	return o.set, o.empty, o.isDefault
+}
+
+// optionalValue 由 Optional 实现，环境变量不存在或者为空时 setField 也需要记录下来
+type optionalValue interface {
+	setPresence(set, empty, isDefault bool)
+}
+
+// presencer 由 Optional 实现，Marshal 需要跳过没有设置的字段
+type presencer interface {
+	presence() (set, empty, isDefault bool)
+}
+
+func asOptionalValue(field reflect.Value) optionalValue {
+	if field.Kind() == reflect.Ptr || !field.CanAddr() {
+		return nil
+	}
+	ov, _ := field.Addr().Interface().(optionalValue)
+	return ov
+}
+
+// presenceOf 根据原始的环境变量判断 key 是否存在，和 getOr 使用同样的默认值规则
+// 设置了 FileSuffix 时 KEY_FILE 存在也算设置了值
+func presenceOf(fieldParams FieldParams, opts Options) (set, empty, isDefault bool) {
+	key, fileKey, _ := lookupFileKey(fieldParams, opts)
+	if fileKey != "" {
+		return true, false, false
+	}
+	raw, set := opts.Environment[key]
+	if fieldParams.Key == "" {
+		raw, set = "", false
+	}
+	return set, set && raw == "", fieldParams.HasDefaultValue && raw == ""
+}
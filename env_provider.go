@@ -0,0 +1,286 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider 从密钥管理服务中读取值，ref 来自字段的 envSecret tag
+//
+//	type Config struct {
+//		Password string `env:"DB_PASSWORD" envSecret:"db/password"`
+//	}
+//
+// 环境变量有值时优先使用环境变量，否则从 SecretProvider 读取，都没有时才使用 envDefault
+// 带有 envSecret 的字段默认是 secret 字段
+type SecretProvider interface {
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// BatchSecretProvider 可以一次读取多个值，解析前会用它读取环境变量中没有值的字段的 ref
+// 单个 ref 读取失败时返回 SecretRefErrors，这些 ref 不会再用 Get 重新读取
+// 返回其他错误，或者返回的 map 中没有的 ref，会再用 Get 单独读取
+type BatchSecretProvider interface {
+	SecretProvider
+	GetMany(ctx context.Context, refs []string) (map[string]string, error)
+}
+
+// SecretRefErrors 是 GetMany 中每个读取失败的 ref 对应的错误
+type SecretRefErrors map[string]error
+
+func (e SecretRefErrors) Error() string {
+	refs := make([]string, 0, len(e))
+	for ref := range e {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	msgs := make([]string, len(refs))
+	for i, ref := range refs {
+		msgs[i] = fmt.Sprintf("%s: %v", ref, e[ref])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// SecretSourcePrefix 加上 ref 是从 SecretProvider 读取的字段在 Resolution 和 Report 中的来源
+const SecretSourcePrefix = "secret:"
+
+// ErrNoSecretProvider 表示字段有 envSecret tag，但是没有设置 Options.SecretProvider
+var ErrNoSecretProvider = errors.New("no secret provider")
+
+type secretResult struct {
+	value string
+	err   error
+}
+
+// needsSecret 判断字段是否需要从 SecretProvider 读取，只有环境变量中没有值时才需要
+func needsSecret(fieldParams FieldParams, opts Options) bool {
+	if fieldParams.SecretRef == "" {
+		return false
+	}
+	key, fileKey, err := lookupFileKey(fieldParams, opts)
+	return err == nil && fileKey == "" && opts.Environment[key] == ""
+}
+
+// fetchSecrets 一次读取所有 refs 的值，refs 不能重复
+func fetchSecrets(refs []string, opts Options) map[string]secretResult {
+	if len(refs) == 0 || opts.SecretProvider == nil {
+		return nil
+	}
+
+	results := make(map[string]secretResult, len(refs))
+	if batch, ok := opts.SecretProvider.(BatchSecretProvider); ok {
+		values, err := batch.GetMany(opts.Context, refs)
+		var refErrs SecretRefErrors
+		if err == nil || errors.As(err, &refErrs) {
+			for ref, value := range values {
+				results[ref] = secretResult{value: value}
+			}
+			for ref, err := range refErrs {
+				results[ref] = secretResult{err: err}
+			}
+		}
+	}
+	for _, ref := range refs {
+		if _, ok := results[ref]; !ok {
+			value, err := opts.SecretProvider.Get(opts.Context, ref)
+			results[ref] = secretResult{value, err}
+		}
+	}
+	return results
+}
+
+// getSecret 返回字段 envSecret 引用的值，优先使用解析前批量读取的结果
+func (opts *Options) getSecret(fieldParams FieldParams) (string, error) {
+	ref := fieldParams.SecretRef
+	result, ok := opts.secrets[ref]
+	if !ok {
+		if opts.SecretProvider == nil {
+			return "", newSecretProviderError(fieldParams.Key, ref, ErrNoSecretProvider)
+		}
+		result.value, result.err = opts.SecretProvider.Get(opts.Context, ref)
+	}
+	if result.err != nil {
+		return "", newSecretProviderError(fieldParams.Key, ref, result.err)
+	}
+	return result.value, nil
+}
+
+// CachedSecretProvider 缓存 SecretProvider 读取的值，适合和 Holder 一起使用，避免每次 Reload 都请求密钥管理服务
+// 读取失败的结果不会被缓存
+type CachedSecretProvider struct {
+	provider SecretProvider
+	ttl      time.Duration
+	mu       sync.Mutex
+	cache    map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// NewCachedSecretProvider 返回缓存 ttl 时间的 SecretProvider，ttl 为 0 时一直缓存
+func NewCachedSecretProvider(provider SecretProvider, ttl time.Duration) *CachedSecretProvider {
+	return &CachedSecretProvider{
+		provider: provider,
+		ttl:      ttl,
+		cache:    make(map[string]cachedSecret),
+	}
+}
+
+func (p *CachedSecretProvider) Get(ctx context.Context, ref string) (string, error) {
+	if value, ok := p.cached(ref); ok {
+		return value, nil
+	}
+	value, err := p.provider.Get(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	p.store(map[string]string{ref: value})
+	return value, nil
+}
+
+// GetMany 只读取没有缓存的 ref，底层的 SecretProvider 不支持批量读取时逐个读取
+func (p *CachedSecretProvider) GetMany(ctx context.Context, refs []string) (map[string]string, error) {
+	values := make(map[string]string, len(refs))
+	var missing []string
+	for _, ref := range refs {
+		if value, ok := p.cached(ref); ok {
+			values[ref] = value
+		} else {
+			missing = append(missing, ref)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	batch, ok := p.provider.(BatchSecretProvider)
+	if !ok {
+		refErrs := make(SecretRefErrors)
+		for _, ref := range missing {
+			value, err := p.Get(ctx, ref)
+			if err != nil {
+				refErrs[ref] = err
+				continue
+			}
+			values[ref] = value
+		}
+		if len(refErrs) > 0 {
+			return values, refErrs
+		}
+		return values, nil
+	}
+	fetched, err := batch.GetMany(ctx, missing)
+	var refErrs SecretRefErrors
+	if err != nil && !errors.As(err, &refErrs) {
+		return nil, err
+	}
+	p.store(fetched)
+	for ref, value := range fetched {
+		values[ref] = value
+	}
+	return values, err
+}
+
+func (p *CachedSecretProvider) cached(ref string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	secret, ok := p.cache[ref]
+	if !ok || (p.ttl > 0 && time.Now().After(secret.expires)) {
+		return "", false
+	}
+	return secret.value, true
+}
+
+func (p *CachedSecretProvider) store(values map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ref, value := range values {
+		p.cache[ref] = cachedSecret{value, time.Now().Add(p.ttl)}
+	}
+}
+
+// FileSecretProvider 从本地的 JSON 文件中读取值，用来在本地和测试中代替密钥管理服务
+// ref 用 "/" 访问嵌套的对象，比如 "db/password" 对应 {"db": {"password": "..."}}
+// 每次解析都会重新读取文件，FS 为 nil 时读取本地文件
+type FileSecretProvider struct {
+	Path string
+	FS   fs.FS
+}
+
+func (p FileSecretProvider) Get(ctx context.Context, ref string) (string, error) {
+	values, err := p.GetMany(ctx, []string{ref})
+	var refErrs SecretRefErrors
+	if errors.As(err, &refErrs) {
+		return "", refErrs[ref]
+	}
+	if err != nil {
+		return "", err
+	}
+	return values[ref], nil
+}
+
+// GetMany 只读取一次文件，文件中没有的 ref 在返回的 SecretRefErrors 中
+func (p FileSecretProvider) GetMany(_ context.Context, refs []string) (map[string]string, error) {
+	var b []byte
+	var err error
+	if p.FS == nil {
+		b, err = os.ReadFile(p.Path)
+	} else {
+		b, err = fs.ReadFile(p.FS, fsPath(p.Path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", p.Path, err)
+	}
+
+	values := make(map[string]string, len(refs))
+	refErrs := make(SecretRefErrors)
+	for _, ref := range refs {
+		value, ok := lookupSecretRef(doc, ref)
+		if !ok {
+			refErrs[ref] = fmt.Errorf("secret %q not found in %s", ref, p.Path)
+			continue
+		}
+		values[ref] = value
+	}
+	if len(refErrs) > 0 {
+		return values, refErrs
+	}
+	return values, nil
+}
+
+func lookupSecretRef(doc interface{}, ref string) (string, bool) {
+	for _, part := range strings.Split(ref, "/") {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if doc, ok = obj[part]; !ok {
+			return "", false
+		}
+	}
+	switch value := doc.(type) {
+	case string:
+		return value, true
+	case json.Number, bool:
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
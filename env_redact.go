@@ -0,0 +1,304 @@
+package env
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Redacted 用来替换 secret 字段的值
+const Redacted = "[REDACTED]"
+
+type redactedError struct {
+	err    error
+	values []string
+}
+
+// Error 只替换带引号的值，strconv、url 等的错误信息都用 %q 输出原始值
+// 直接替换 value 会把很短的值在错误信息的其他地方也替换掉，比如 "a" 会把 ParseInt 变成 P[REDACTED]rseInt
+func (e redactedError) Error() string {
+	msg := e.err.Error()
+	for _, value := range e.values {
+		msg = strings.ReplaceAll(msg, strconv.Quote(value), strconv.Quote(Redacted))
+	}
+	return msg
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError 把错误信息中出现的 secret 字段的值替换成 Redacted
+// 比如 url.Parse 和 strconv 的错误信息里会带上原始值
+func redactError(err error, fieldParams FieldParams, values ...string) error {
+	if err == nil || !fieldParams.Secret {
+		return err
+	}
+	values = nonEmpty(values)
+	if len(values) == 0 {
+		return err
+	}
+	switch e := err.(type) {
+	case AggregateError:
+		for i, ie := range e.Errors {
+			e.Errors[i] = redactError(ie, fieldParams, values...)
+		}
+		return e
+	case ParseError:
+		e.Err = redactedError{e.Err, values}
+		return e
+	case ValidationError:
+		e.Value = Redacted
+		return e
+	}
+	return redactedError{err, values}
+}
+
+// secretParts 返回值本身和 slice、map 拆分出来的每一部分，从长到短排序
+// handleSlice 和 setMap 的错误信息中只有解析失败的那一个元素或者 key、value
+//
+//	"1,a:hunter2" => ["1,a:hunter2", "a:hunter2", "hunter2", "1", "a"]
+func secretParts(sf reflect.StructField, value string) []string {
+	separator := sf.Tag.Get("envSeparator")
+	if separator == "" {
+		separator = ","
+	}
+	keyValSeparator := sf.Tag.Get("envKeyValSeparator")
+	if keyValSeparator == "" {
+		keyValSeparator = ":"
+	}
+
+	seen := map[string]bool{value: true}
+	parts := []string{value}
+	add := func(part string) {
+		if !seen[part] {
+			seen[part] = true
+			parts = append(parts, part)
+		}
+	}
+	for _, part := range strings.Split(value, separator) {
+		add(part)
+		for _, pair := range strings.Split(part, keyValSeparator) {
+			add(pair)
+		}
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		return len(parts[i]) > len(parts[j])
+	})
+	return parts
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Redact 返回和 fmt 的 %+v 一样格式的字符串，带有 secret 选项的字段会被替换成 Redacted
+// 可以用来实现配置结构体的 String 方法
+//
+//	func (c Config) String() string {
+//		return env.Redact(c)
+//	}
+func Redact(v interface{}) string {
+	return RedactWithOptions(v, Options{})
+}
+
+// RedactWithOptions 和 Redact 一样，使用 opts 中的 TagName 判断 secret 字段
+func RedactWithOptions(v interface{}, opts Options) string {
+	var sb strings.Builder
+	writeRedacted(&sb, reflect.ValueOf(v), redactOptions(opts))
+	return sb.String()
+}
+
+func writeRedacted(sb *strings.Builder, v reflect.Value, opts Options) {
+	if !v.IsValid() {
+		sb.WriteString("<nil>")
+		return
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			sb.WriteString("<nil>")
+			return
+		}
+		sb.WriteString("&")
+		v = v.Elem()
+	}
+	if !hasSecretFields(v.Type(), opts) {
+		fmt.Fprintf(sb, "%+v", v)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		sb.WriteString("{")
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			sf := v.Type().Field(i)
+			sb.WriteString(sf.Name + ":")
+			if isSecretField(sf, opts) {
+				sb.WriteString(Redacted)
+				continue
+			}
+			writeRedacted(sb, v.Field(i), opts)
+		}
+		sb.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		sb.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			writeRedacted(sb, v.Index(i), opts)
+		}
+		sb.WriteString("]")
+	case reflect.Map:
+		sb.WriteString("map[")
+		for i, key := range sortedMapKeys(v) {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(sb, "%+v:", key)
+			writeRedacted(sb, v.MapIndex(key), opts)
+		}
+		sb.WriteString("]")
+	}
+}
+
+// RedactedLogValue 把结构体转换成 slog.Value，带有 secret 选项的字段会被替换成 Redacted
+// 可以用来实现配置结构体的 slog.LogValuer
+//
+//	func (c Config) LogValue() slog.Value {
+//		return env.RedactedLogValue(c)
+//	}
+func RedactedLogValue(v interface{}) slog.Value {
+	return RedactedLogValueWithOptions(v, Options{})
+}
+
+// RedactedLogValueWithOptions 和 RedactedLogValue 一样，使用 opts 中的 TagName 判断 secret 字段
+func RedactedLogValueWithOptions(v interface{}, opts Options) slog.Value {
+	return redactedLogValue(reflect.ValueOf(v), redactOptions(opts))
+}
+
+func redactedLogValue(v reflect.Value, opts Options) slog.Value {
+	if !v.IsValid() {
+		return slog.AnyValue(nil)
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if !hasSecretFields(v.Type(), opts) && v.CanInterface() {
+			if _, ok := v.Interface().(fmt.Stringer); ok {
+				return slog.AnyValue(v.Interface())
+			}
+		}
+		var attrs []slog.Attr
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if isSecretField(sf, opts) {
+				attrs = append(attrs, slog.String(sf.Name, Redacted))
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: sf.Name, Value: redactedLogValue(v.Field(i), opts)})
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Slice, reflect.Array:
+		if !hasSecretFields(v.Type(), opts) {
+			break
+		}
+		attrs := make([]slog.Attr, v.Len())
+		for i := range attrs {
+			attrs[i] = slog.Attr{Key: strconv.Itoa(i), Value: redactedLogValue(v.Index(i), opts)}
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Map:
+		if !hasSecretFields(v.Type(), opts) {
+			break
+		}
+		var attrs []slog.Attr
+		for _, key := range sortedMapKeys(v) {
+			attrs = append(attrs, slog.Attr{Key: fmt.Sprint(key), Value: redactedLogValue(v.MapIndex(key), opts)})
+		}
+		return slog.GroupValue(attrs...)
+	}
+
+	if !v.CanInterface() {
+		return slog.StringValue(fmt.Sprintf("%+v", v))
+	}
+	return slog.AnyValue(v.Interface())
+}
+
+// redactOptions 只补上判断 secret 字段需要的默认值，Redact 可能在每次打印日志时调用，不读取环境变量
+func redactOptions(opts Options) Options {
+	if opts.TagName == "" {
+		opts.TagName = "env"
+	}
+	if opts.SecretTagName == "" {
+		opts.SecretTagName = "envSecret"
+	}
+	return opts
+}
+
+// isSecretField 和 parseFieldParams 一样，带有 secret 选项、envSecret tag 或者是 Secret 类型的字段都是 secret 字段
+func isSecretField(sf reflect.StructField, opts Options) bool {
+	if sf.Tag.Get(opts.SecretTagName) != "" || hasSecretType(sf.Type) {
+		return true
+	}
+	_, tags := parseKeyForOption(sf.Tag.Get(opts.TagName))
+	for _, tag := range tags {
+		if tag == "secret" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSecretFields 判断类型中是否有 secret 字段，包括嵌套的结构体、slice 和 map 中的结构体
+func hasSecretFields(typee reflect.Type, opts Options) bool {
+	return hasSecretFieldsSeen(typee, opts, map[reflect.Type]bool{})
+}
+
+func hasSecretFieldsSeen(typee reflect.Type, opts Options, seen map[reflect.Type]bool) bool {
+	switch typee.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSecretFieldsSeen(typee.Elem(), opts, seen)
+	case reflect.Struct:
+		if seen[typee] {
+			return false
+		}
+		seen[typee] = true
+		for i := 0; i < typee.NumField(); i++ {
+			sf := typee.Field(i)
+			if isSecretField(sf, opts) || hasSecretFieldsSeen(sf.Type, opts, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
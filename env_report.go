@@ -0,0 +1,57 @@
+package env
+
+// Report 记录每个字段的值是从哪里来的
+type Report struct {
+	Fields []FieldReport
+}
+
+type FieldReport struct {
+	// 字段路径，比如 "Database.Host"
+	Path string
+	// 读取的环境变量
+	Key string
+	// 读取到的原始值，还没有经过 expand 和 file 处理，secret 字段的值会被替换成 Redacted
+	Value  string
+	Secret bool
+	// 环境变量中是否存在这个 key
+	Exists bool
+	// 是否使用了 envDefault
+	IsDefault bool
+	Expanded  bool
+	// file 选项读取的文件名
+	File string
+	// 提供这个值的 Source，没有找到值时为空
+	Source string
+	Err    error
+}
+
+func ParseWithReport(v interface{}, opts Options) (Report, error) {
+	o := customOptions(opts)
+	o.report = &Report{}
+	err := parse(v, o)
+	return *o.report, err
+}
+
+func (r Report) Field(path string) (FieldReport, bool) {
+	for _, f := range r.Fields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return FieldReport{}, false
+}
+
+// reportError 把 get 之后才发生的错误记录到字段的 FieldReport 中，比如 set、envValidate 和条件检查的错误
+func (opts Options) reportError(path string, err error) {
+	if err == nil || opts.report == nil {
+		return
+	}
+	for i := len(opts.report.Fields) - 1; i >= 0; i-- {
+		if field := &opts.report.Fields[i]; field.Path == path {
+			if field.Err == nil {
+				field.Err = err
+			}
+			return
+		}
+	}
+}
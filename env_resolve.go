@@ -0,0 +1,90 @@
+package env
+
+import (
+	"encoding/base64"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ResolverFunc 根据 "scheme:ref" 中的 ref 返回真正的值
+// 通过 Options.Resolvers 按 scheme 注册，会覆盖内置的 file、env、base64
+// 带有 resolve 选项的字段，或者 Options.ResolveAll 为 true 时所有字段，在 set 之前解析
+//
+//	type Config struct {
+//		Token string `env:"TOKEN,resolve"` // TOKEN=file:///run/secrets/token
+//	}
+type ResolverFunc func(ref string) (string, error)
+
+// ExecResolver 执行 ref 中的命令，返回去掉末尾换行的标准输出
+// 命令按空白分隔参数，不经过 shell。因为会执行任意命令，默认不启用，需要手动注册
+//
+//	env.Options{Resolvers: map[string]env.ResolverFunc{"exec": env.ExecResolver}}
+func ExecResolver(ref string) (string, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// resolver 返回 scheme 对应的 ResolverFunc，Options.Resolvers 中的优先
+// 内置的有 file、env 和 base64，file 和 file 选项一样使用 Options.FS 和 Options.BaseDir
+func (opts *Options) resolver(scheme string) ResolverFunc {
+	if fn, ok := opts.Resolvers[scheme]; ok {
+		return fn
+	}
+	switch scheme {
+	case "file":
+		return func(ref string) (string, error) {
+			return getFromFile(opts.FS, opts.filePath(filePathOfURI(ref)))
+		}
+	case "env":
+		return func(ref string) (string, error) {
+			value, ok := opts.Environment[ref]
+			if !ok {
+				return "", newVarIsNotSetError(ref)
+			}
+			return value, nil
+		}
+	case "base64":
+		return func(ref string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(ref)
+			return string(b), err
+		}
+	}
+	return nil
+}
+
+// resolve 在 value 以注册过的 scheme 开头时调用对应的 ResolverFunc
+// 其他值原样返回，比如 postgres://host 这样没有注册的 scheme
+func (opts *Options) resolve(key, value string) (string, string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, "", nil
+	}
+	fn := opts.resolver(scheme)
+	if fn == nil {
+		return value, "", nil
+	}
+	resolved, err := fn(ref)
+	if err != nil {
+		return "", scheme, newResolveError(key, scheme, err)
+	}
+	return resolved, scheme, nil
+}
+
+// filePathOfURI 把 file:///run/secrets/token 中的 ///run/secrets/token 转换成路径
+// 也支持 file:relative/path 这样的写法
+func filePathOfURI(ref string) string {
+	if rest, ok := strings.CutPrefix(ref, "//"); ok {
+		// file://localhost/path 中的 host 只能是空或者 localhost
+		rest = strings.TrimPrefix(rest, "localhost")
+		return rest
+	}
+	return ref
+}
@@ -0,0 +1,88 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// Secret 保存一个不应该出现在日志中的值，解析方式和 T 一样
+// 打印、序列化时都只会输出 Redacted，只有 Reveal 能拿到真实的值
+//
+//	type Config struct {
+//		Token env.Secret[string] `env:"TOKEN"`
+//	}
+type Secret[T any] struct {
+	value T
+}
+
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value}
+}
+
+func (s Secret[T]) Reveal() T {
+	return s.value
+}
+
+func (s Secret[T]) String() string {
+	return Redacted
+}
+
+func (s Secret[T]) GoString() string {
+	return Redacted
+}
+
+// Format 保证 %v、%+v、%#v、%s 等所有格式都不会输出真实的值
+func (s Secret[T]) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(Redacted))
+}
+
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+func (s Secret[T]) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// UnmarshalText 让 Secret 在 slice 或者其他解析器中也能使用，只支持默认的解析函数
+func (s *Secret[T]) UnmarshalText(text []byte) error {
+	return s.setInner(string(text), reflect.StructField{}, defaultTypeParsers())
+}
+
+// setInner 自己隐藏错误中的值，UnmarshalText 这样不经过 setField 的调用也不会泄漏
+func (s *Secret[T]) setInner(value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error {
+	err := setInner(&s.value, value, sf, funcMap)
+	return redactError(err, FieldParams{Secret: true}, secretParts(sf, value)...)
+}
+
+func (s Secret[T]) reveal() interface{} {
+	return s.value
+}
+
+func (s Secret[T]) secret() {}
+
+// secretMarker 用来判断字段是不是 Secret
+type secretMarker interface {
+	secret()
+}
+
+var secretMarkerType = reflect.TypeOf((*secretMarker)(nil)).Elem()
+
+func isSecretType(typee reflect.Type) bool {
+	return typee.Implements(secretMarkerType) || reflect.PointerTo(typee).Implements(secretMarkerType)
+}
+
+// hasSecretType 判断字段是不是 Secret，或者是 Secret 的 slice、map
+func hasSecretType(typee reflect.Type) bool {
+	switch typee.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return isSecretType(typee.Elem())
+	}
+	return isSecretType(typee)
+}
@@ -0,0 +1,128 @@
+package env
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const defaultSecretMaxSize = 1 << 20
+
+// SecretsDir 把目录中的每个文件读成一个环境变量，文件名就是 key，文件内容就是 value
+// Kubernetes 和 Docker 都是这样挂载 secret 的
+//
+//	/run/secrets/db-password => DB_PASSWORD
+//	/run/secrets/db/user     => DB_USER
+//
+// Kubernetes 挂载的目录中，真正的文件在 ..data 指向的目录里，外层的文件都是符号链接，
+// 所以以 ".." 开头的文件和目录会被跳过，符号链接会读取它指向的文件
+type SecretsDir struct {
+	Dir string
+	// FS 为 nil 时使用 Options.FS 和 Options.BaseDir，Options.FS 也为 nil 时读取本地目录
+	FS fs.FS
+	// 加在每个 key 前面
+	Prefix string
+	// 把文件的相对路径转换成 key，默认转成大写，"-"、"."、"/" 转成 "_"
+	KeyFunc func(name string) string
+	// 单个文件的最大字节数，默认 1MB
+	MaxSize int64
+	// 去掉文件末尾的换行符
+	TrimNewline bool
+}
+
+func SecretsDirSource(dir string) Source {
+	return SecretsDir{Dir: dir, TrimNewline: true}
+}
+
+func (s SecretsDir) Name() string {
+	return "secrets:" + s.Dir
+}
+
+func (s SecretsDir) withFS(fsys fs.FS, baseDir string) Source {
+	if s.FS != nil {
+		return s
+	}
+	s.FS = fsys
+	if baseDir != "" && s.Dir != "" && !filepath.IsAbs(s.Dir) && !strings.HasPrefix(s.Dir, "/") {
+		s.Dir = filepath.Join(baseDir, s.Dir)
+	}
+	return s
+}
+
+func (s SecretsDir) Load() (map[string]string, error) {
+	fsys, root := s.FS, "."
+	if fsys == nil {
+		fsys = os.DirFS(s.Dir)
+	} else if s.Dir != "" {
+		root = fsPath(s.Dir)
+	}
+
+	keyFunc := s.KeyFunc
+	if keyFunc == nil {
+		keyFunc = secretKey
+	}
+	maxSize := s.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultSecretMaxSize
+	}
+
+	result := make(map[string]string)
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		// fs.Stat 会跟随符号链接
+		info, err := fs.Stat(fsys, p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Size() > maxSize {
+			return fmt.Errorf("file %q is larger than %d bytes", p, maxSize)
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		value := string(b)
+		if s.TrimNewline {
+			value = strings.TrimRight(value, "\r\n")
+		}
+
+		rel := strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			rel = p
+		}
+		result[s.Prefix+keyFunc(rel)] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// "db/api-key.txt" => "DB_API_KEY_TXT"
+func secretKey(name string) string {
+	name = path.Clean(name)
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+}
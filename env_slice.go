@@ -0,0 +1,158 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func isStructSlice(typee reflect.Type) bool {
+	if typee.Kind() != reflect.Slice {
+		return false
+	}
+	elem := typee.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// doParseSlice 解析 []struct 类型的字段，每个元素的前缀是 envPrefix + 下标
+//
+//	type Config struct {
+//		DBs []DB `envPrefix:"DB_"`
+//	}
+//
+// DB_0_HOST=a DB_1_HOST=b => DBs[0].Host=a DBs[1].Host=b
+func doParseSlice(refField reflect.Value, refTypeField reflect.StructField, processField processFieldFn, opts Options) error {
+	prefix := opts.Prefix + refTypeField.Tag.Get(opts.PrefixTagName)
+
+	if opts.example {
+		return doParsePlaceholder(refField.Type().Elem(), processField, opts, prefix, "<n>")
+	}
+
+	// 只有真正解析时才从环境变量中找下标，Marshal 等只处理已有的元素
+	length := refField.Len()
+	if opts.parsing {
+		indices := sliceIndices(prefix, opts.Environment)
+		if len(indices) > 0 {
+			last := indices[len(indices)-1]
+			// 下标必须连续，已有的元素也算
+			for i, idx := 0, 0; i <= last; i++ {
+				if idx < len(indices) && indices[idx] == i {
+					idx++
+					continue
+				}
+				if i >= length {
+					return newParseError(refTypeField, fmt.Errorf("index %d of %q is missing, found index %d", i, prefix, last))
+				}
+			}
+			length = max(length, last+1)
+		}
+	}
+	if length == 0 {
+		return nil
+	}
+
+	elemType := refField.Type().Elem()
+	result := reflect.MakeSlice(refField.Type(), length, length)
+	reflect.Copy(result, refField)
+
+	var agrErr AggregateError
+	for i := 0; i < length; i++ {
+		elem := result.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elemType.Elem()))
+			}
+			elem = elem.Elem()
+		}
+
+		elemOpts := opts
+		elemOpts.Prefix = prefix + strconv.Itoa(i) + "_"
+		elemOpts.fieldPath = fmt.Sprintf("%s[%d]", opts.fieldPath, i)
+		elemOpts.indexed = true
+		if err := doParse(elem, processField, elemOpts); err != nil {
+			var val AggregateError
+			if errors.As(err, &val) {
+				agrErr.Errors = append(agrErr.Errors, val.Errors...)
+			} else {
+				agrErr.Errors = append(agrErr.Errors, err)
+			}
+		}
+	}
+
+	refField.Set(result)
+	if len(agrErr.Errors) == 0 {
+		return nil
+	}
+	return agrErr
+}
+
+// sliceIndices 找出所有 prefix + 数字 + "_" 开头的 key 中的数字，去重后从小到大排序
+func sliceIndices(prefix string, env map[string]string) []int {
+	seen := make(map[int]bool)
+	for key := range env {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		digits, _, ok := strings.Cut(rest, "_")
+		if !ok {
+			continue
+		}
+		idx, err := strconv.Atoi(digits)
+		// 排除 "01"、"+1" 这样的写法
+		if err != nil || idx < 0 || strconv.Itoa(idx) != digits {
+			continue
+		}
+		seen[idx] = true
+	}
+
+	indices := make([]int, 0, len(seen))
+	for idx := range seen {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// doParsePlaceholder 用一个空的元素处理 []struct 和 map[string]struct 的字段
+// WriteExample 用它输出 DB_<n>_HOST 这样的 key，而不是依赖环境变量中已有的 key
+func doParsePlaceholder(elemType reflect.Type, processField processFieldFn, opts Options, prefix, segment string) error {
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	elemOpts := opts
+	elemOpts.Prefix = prefix + segment + "_"
+	elemOpts.fieldPath = fmt.Sprintf("%s[%s]", opts.fieldPath, segment)
+	elemOpts.indexed = true
+	return doParse(reflect.New(elemType).Elem(), processField, elemOpts)
+}
+
+// withFieldPath 让切片元素中的错误带上完整的字段路径，比如 "DBs[1].Port"
+func (opts Options) withFieldPath(err error) error {
+	if err == nil || !opts.indexed {
+		return err
+	}
+	switch e := err.(type) {
+	case AggregateError:
+		for i, ie := range e.Errors {
+			e.Errors[i] = opts.withFieldPath(ie)
+		}
+		return e
+	case ParseError:
+		e.Name = opts.fieldPath
+		return e
+	case NoParserError:
+		e.Name = opts.fieldPath
+		return e
+	case ValidationError:
+		e.Field = opts.fieldPath
+		return e
+	}
+	return err
+}
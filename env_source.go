@@ -0,0 +1,221 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// 没有命中任何 Source 而使用 envDefault 时，Resolution 中记录的来源
+const DefaultSourceName = "default"
+
+// Source 是一层配置来源，Options.Sources 按优先级从高到低排列，
+// 解析字段时从第一层开始查找，第一个包含该 key 的 Source 胜出
+type Source interface {
+	Name() string
+	Load() (map[string]string, error)
+}
+
+// fileSource 是读取文件的 Source，没有设置自己的文件系统时使用 Options.FS 和 Options.BaseDir
+type fileSource interface {
+	withFS(fsys fs.FS, baseDir string) Source
+}
+
+// Resolution 记录每个字段的 key 最终由哪一层 Source 提供
+type Resolution map[string]string
+
+type sourceFunc struct {
+	name string
+	load func() (map[string]string, error)
+}
+
+func (s sourceFunc) Name() string {
+	return s.name
+}
+
+func (s sourceFunc) Load() (map[string]string, error) {
+	return s.load()
+}
+
+func NewSource(name string, load func() (map[string]string, error)) Source {
+	return sourceFunc{name, load}
+}
+
+// fileSourceFunc 读取 paths 中的文件，loadSources 会传入 Options.FS 和 Options.BaseDir
+type fileSourceFunc struct {
+	name  string
+	paths []string
+	fsys  fs.FS
+	load  func(fsys fs.FS, paths []string) (map[string]string, error)
+}
+
+func (s fileSourceFunc) Name() string {
+	return s.name
+}
+
+func (s fileSourceFunc) Load() (map[string]string, error) {
+	return s.load(s.fsys, s.paths)
+}
+
+func (s fileSourceFunc) withFS(fsys fs.FS, baseDir string) Source {
+	opts := Options{BaseDir: baseDir}
+	paths := make([]string, len(s.paths))
+	for i, path := range s.paths {
+		paths[i] = opts.filePath(path)
+	}
+	s.fsys, s.paths = fsys, paths
+	return s
+}
+
+func EnvSource() Source {
+	return NewSource("env", func() (map[string]string, error) {
+		return toMap(os.Environ()), nil
+	})
+}
+
+func MapSource(name string, values map[string]string) Source {
+	return NewSource(name, func() (map[string]string, error) {
+		return values, nil
+	})
+}
+
+func DotenvSource(paths ...string) Source {
+	name := "dotenv"
+	if len(paths) > 0 {
+		name += ":" + strings.Join(paths, ",")
+	} else {
+		paths = []string{".env"}
+	}
+	return fileSourceFunc{name: name, paths: paths, load: loadDotenv}
+}
+
+// JSONSource 读取一个 JSON 对象，嵌套对象的 key 用 "_" 连接，数组用 "," 连接
+// {"DB": {"HOST": "localhost"}, "PORTS": [1, 2]} => DB_HOST=localhost, PORTS=1,2
+func JSONSource(path string) Source {
+	return fileSourceFunc{name: "json:" + path, paths: []string{path}, load: loadJSON}
+}
+
+func loadJSON(fsys fs.FS, paths []string) (map[string]string, error) {
+	data, err := getFromFile(fsys, paths[0])
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	if err := flattenJSON("", raw, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func flattenJSON(prefix string, raw map[string]interface{}, result map[string]string) error {
+	for k, v := range raw {
+		key := prefix + k
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if err := flattenJSON(key+"_", val, result); err != nil {
+				return err
+			}
+		case []interface{}:
+			parts := make([]string, 0, len(val))
+			for _, item := range val {
+				s, err := jsonScalar(key, item)
+				if err != nil {
+					return err
+				}
+				parts = append(parts, s)
+			}
+			result[key] = strings.Join(parts, ",")
+		default:
+			s, err := jsonScalar(key, val)
+			if err != nil {
+				return err
+			}
+			result[key] = s
+		}
+	}
+	return nil
+}
+
+func jsonScalar(key string, v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case json.Number, bool:
+		return fmt.Sprint(val), nil
+	}
+	return "", fmt.Errorf("unsupported value for key %q", key)
+}
+
+func ParseWithResolution(v interface{}, opts Options) (Resolution, error) {
+	o := customOptions(opts)
+	o.resolution = make(Resolution)
+	err := parse(v, o)
+	return o.resolution, err
+}
+
+// loadSources 把 Sources 按优先级合并到 Environment 中，并记录每个 key 的来源
+// 直接传入的 Options.Environment 优先级最高
+func (opts *Options) loadSources() error {
+	if len(opts.Sources) == 0 {
+		return nil
+	}
+
+	env := make(map[string]string)
+	origins := make(map[string]string)
+	var agrErr AggregateError
+	for i := len(opts.Sources) - 1; i >= 0; i-- {
+		source := opts.Sources[i]
+		name := source.Name()
+		if fsSrc, ok := source.(fileSource); ok {
+			source = fsSrc.withFS(opts.FS, opts.BaseDir)
+		}
+		values, err := source.Load()
+		if err != nil {
+			var val AggregateError
+			if !errors.As(err, &val) {
+				val.Errors = []error{err}
+			}
+			for _, e := range val.Errors {
+				agrErr.Errors = append(agrErr.Errors, newLoadSourceError(name, e))
+			}
+			continue
+		}
+		for k, val := range values {
+			env[k] = val
+			origins[k] = name
+		}
+	}
+	if len(agrErr.Errors) != 0 {
+		return agrErr
+	}
+
+	for k, val := range opts.Environment {
+		env[k] = val
+		origins[k] = "options"
+	}
+
+	opts.Environment = env
+	opts.origins = origins
+	return nil
+}
+
+func (opts *Options) sourceOf(key string, isDefault bool) string {
+	if isDefault {
+		return DefaultSourceName
+	}
+	if name, ok := opts.origins[key]; ok {
+		return name
+	}
+	return "env"
+}
@@ -1,9 +1,23 @@
 package env
 
-import "reflect"
+import (
+	"context"
+	"io/fs"
+	"reflect"
+)
 
 type OnSetFn func(tag string, value interface{}, isDefault bool)
 
+// Validator 在结构体的字段全部解析完成后被调用，适合放跨字段的校验
+type Validator interface {
+	Validate() error
+}
+
+// ParsedHook 在结构体的字段全部解析完成后、Validate 之前被调用
+type ParsedHook interface {
+	EnvParsed() error
+}
+
 type ParserFunc func(v string) (interface{}, error)
 
 type processFieldFn func(refField reflect.Value, refTypeField reflect.StructField, opts Options, fieldParams FieldParams) error
@@ -12,13 +26,37 @@ type Options struct {
 	Environment           map[string]string
 	TagName               string
 	DefaultValueTagName   string
+	DescriptionTagName    string
+	ValidateTagName       string
+	SecretTagName         string
 	PrefixTagName         string
 	Prefix                string
 	FuncMap               map[reflect.Type]ParserFunc
-	rawEnvVars            map[string]string
+	rawEnvVars            map[string]rawEnvVar
 	UseFieldNameByDefault bool
 	RequiredIfNoDef       bool
 	OnSet                 OnSetFn
+	OnDeprecated          OnDeprecatedFn
+	Resolvers             map[string]ResolverFunc
+	ResolveAll            bool
+	SecretProvider        SecretProvider
+	Context               context.Context
+	DecryptionKey         []byte
+	DecryptionKeyFile     string
+	DecryptionKeyEnv      string
+	Sources               []Source
+	FileSuffix            string
+	FS                    fs.FS
+	BaseDir               string
+	origins               map[string]string
+	resolution            Resolution
+	report                *Report
+	fieldPath             string
+	callHooks             bool
+	parsing               bool
+	example               bool
+	indexed               bool
+	secrets               map[string]secretResult
 }
 
 type FieldParams struct {
@@ -32,4 +70,16 @@ type FieldParams struct {
 	Expand          bool
 	Unset           bool
 	LoadFile        bool
+	Description     string
+	Validate        string
+	RequiredIf      string
+	RequiredWith    string
+	ExcludedWith    string
+	Scan            bool
+	FilePipeline    []string
+	Secret          bool
+	Aliases         []string
+	Deprecated      bool
+	Resolve         bool
+	SecretRef       string
 }
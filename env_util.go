@@ -1,7 +1,10 @@
 package env
 
 import (
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"unicode"
@@ -16,11 +19,37 @@ func isInvalidPtr(v reflect.Value) bool {
 	return reflect.Ptr == v.Kind() && v.Elem().Kind() == reflect.Invalid
 }
 
-func getFromFile(filename string) (value string, err error) {
-	b, err := os.ReadFile(filename)
+// getFromFile 读取文件内容，fsys 为 nil 时直接读取本地文件
+func getFromFile(fsys fs.FS, filename string) (value string, err error) {
+	if fsys == nil {
+		b, err := os.ReadFile(filename)
+		return string(b), err
+	}
+	b, err := fs.ReadFile(fsys, fsPath(filename))
 	return string(b), err
 }
 
+func statFile(fsys fs.FS, filename string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(filename)
+	}
+	return fs.Stat(fsys, fsPath(filename))
+}
+
+// fs.FS 只接受不以 "/" 开头的路径
+// "/run/secrets/db" => "run/secrets/db"
+func fsPath(filename string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(filename), "/"))
+}
+
+// "Nested" + "Field" => "Nested.Field"
+func joinFieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
 const underscore rune = '_'
 
 func toEnvName(input string) string {
@@ -0,0 +1,191 @@
+package env
+
+import (
+	"cmp"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type validationRule struct {
+	Name string
+	Arg  string
+}
+
+// parseValidationRules 解析 envValidate tag
+// "min=1,max=65535" => [{min 1} {max 65535}]
+// regex 的参数里可能有 ","，所以 regex 必须放在最后，后面的内容都属于 regex
+func parseValidationRules(tag string) ([]validationRule, error) {
+	var rules []validationRule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, newNoSupportedTagOptionError(part)
+		}
+		switch name {
+		case "min", "max", "len", "oneof":
+		case "regex":
+			if _, err := regexp.Compile(arg); err != nil {
+				return nil, newNoSupportedTagOptionError(part)
+			}
+		default:
+			return nil, newNoSupportedTagOptionError(part)
+		}
+		rules = append(rules, validationRule{name, arg})
+	}
+	return rules, nil
+}
+
+func validate(field reflect.Value, sf reflect.StructField, fieldParams FieldParams) error {
+	if fieldParams.Validate == "" {
+		return nil
+	}
+	rules, err := parseValidationRules(fieldParams.Validate)
+	if err != nil {
+		return err
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	if r, ok := field.Interface().(revealer); ok {
+		field = reflect.ValueOf(r.reveal())
+	}
+
+	var agrErr AggregateError
+	for _, rule := range rules {
+		if err := checkRule(field, sf, fieldParams, rule); err != nil {
+			agrErr.Errors = append(agrErr.Errors, err)
+		}
+	}
+	if len(agrErr.Errors) == 0 {
+		return nil
+	}
+	return agrErr
+}
+
+func checkRule(field reflect.Value, sf reflect.StructField, fieldParams FieldParams, rule validationRule) error {
+	fail := func(value string) error {
+		return newValidationError(sf.Name, fieldParams.Key, rule.Name+"="+rule.Arg, value)
+	}
+
+	switch rule.Name {
+	case "oneof", "regex":
+		// slice 和 map 对每个元素做校验
+		for _, elem := range validationElems(field) {
+			value, _, _ := formatScalar(elem)
+			if !matchRule(rule, value) {
+				return fail(value)
+			}
+		}
+		return nil
+	}
+
+	value, _ := format(field, sf)
+	ok, err := compareRule(field, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fail(value)
+	}
+	return nil
+}
+
+func validationElems(field reflect.Value) []reflect.Value {
+	switch field.Kind() {
+	case reflect.Slice:
+		elems := make([]reflect.Value, field.Len())
+		for i := range elems {
+			elems[i] = field.Index(i)
+		}
+		return elems
+	case reflect.Map:
+		elems := make([]reflect.Value, 0, field.Len())
+		iter := field.MapRange()
+		for iter.Next() {
+			elems = append(elems, iter.Value())
+		}
+		return elems
+	}
+	return []reflect.Value{field}
+}
+
+func matchRule(rule validationRule, value string) bool {
+	if rule.Name == "regex" {
+		return regexp.MustCompile(rule.Arg).MatchString(value)
+	}
+	for _, option := range strings.Split(rule.Arg, "|") {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+// compareRule 处理 min、max、len
+// 数字比较的是值本身，字符串比较的是字符数，slice 和 map 比较的是元素个数
+func compareRule(field reflect.Value, rule validationRule) (bool, error) {
+	var c int
+	switch field.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		n := field.Len()
+		if field.Kind() == reflect.String {
+			n = utf8.RuneCountInString(field.String())
+		}
+		bound, err := strconv.Atoi(rule.Arg)
+		if err != nil {
+			return false, newNoSupportedTagOptionError(rule.Name + "=" + rule.Arg)
+		}
+		c = cmp.Compare(n, bound)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var bound int64
+		var err error
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			d, err = time.ParseDuration(rule.Arg)
+			bound = int64(d)
+		} else {
+			bound, err = strconv.ParseInt(rule.Arg, 10, 64)
+		}
+		if err != nil || rule.Name == "len" {
+			return false, newNoSupportedTagOptionError(rule.Name + "=" + rule.Arg)
+		}
+		c = cmp.Compare(field.Int(), bound)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bound, err := strconv.ParseUint(rule.Arg, 10, 64)
+		if err != nil || rule.Name == "len" {
+			return false, newNoSupportedTagOptionError(rule.Name + "=" + rule.Arg)
+		}
+		c = cmp.Compare(field.Uint(), bound)
+	case reflect.Float32, reflect.Float64:
+		bound, err := strconv.ParseFloat(rule.Arg, 64)
+		if err != nil || rule.Name == "len" {
+			return false, newNoSupportedTagOptionError(rule.Name + "=" + rule.Arg)
+		}
+		c = cmp.Compare(field.Float(), bound)
+	default:
+		return false, newNoSupportedTagOptionError(rule.Name + "=" + rule.Arg)
+	}
+
+	switch rule.Name {
+	case "min":
+		return c >= 0, nil
+	case "max":
+		return c <= 0, nil
+	}
+	return c == 0, nil
+}
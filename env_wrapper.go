@@ -0,0 +1,43 @@
+package env
+
+import "reflect"
+
+// innerValue 由 Secret 和 Optional 这样包装了一个值的类型实现，
+// set 会用 FuncMap 解析里面的值，而不是把它们当成 TextUnmarshaler
+type innerValue interface {
+	setInner(value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error
+}
+
+// revealer 返回包装的值，Marshal 和 envValidate 需要使用真实的值
+type revealer interface {
+	reveal() interface{}
+}
+
+var innerValueType = reflect.TypeOf((*innerValue)(nil)).Elem()
+
+func setInner(ptr interface{}, value string, sf reflect.StructField, funcMap map[reflect.Type]ParserFunc) error {
+	ref := reflect.ValueOf(ptr).Elem()
+	inner := sf
+	inner.Type = ref.Type()
+	return set(ref, inner, value, funcMap)
+}
+
+func asInnerValue(field reflect.Value) innerValue {
+	typee := field.Type()
+	if typee.Kind() != reflect.Ptr {
+		typee = reflect.PointerTo(typee)
+	}
+	if !typee.Implements(innerValueType) {
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return field.Interface().(innerValue)
+	}
+	if !field.CanAddr() {
+		return nil
+	}
+	return field.Addr().Interface().(innerValue)
+}